@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/go-flutter-desktop/hover/internal/build"
+	"github.com/go-flutter-desktop/hover/internal/hotreload"
+	"github.com/go-flutter-desktop/hover/internal/log"
+	"github.com/go-flutter-desktop/hover/internal/pubspec"
+	"github.com/go-flutter-desktop/hover/internal/vmservice"
+	"github.com/go-flutter-desktop/hover/internal/watch"
+)
+
+var (
+	attachDebugURI        string
+	attachObservatoryPort string
+	attachAssetDirectory  string
+	attachWatch           bool
+	attachArch            string
+)
+
+func init() {
+	initDartFlags(attachCmd)
+
+	attachCmd.Flags().StringVar(&attachDebugURI, "debug-uri", "", "The observatory URI printed by the app, e.g. http://127.0.0.1:50300/")
+	attachCmd.Flags().StringVar(&attachObservatoryPort, "observatory-port", "50300", "The observatory port of the app, set by `hover run` or the --observatory-port VM argument of the app")
+	attachCmd.Flags().StringVar(&attachAssetDirectory, "asset-dir", "", "The flutter_assets directory of the app, used by hot restart. Defaults to the one of the debug build")
+	attachCmd.Flags().BoolVar(&attachWatch, "watch", false, "Hot reload when the dart files in lib/ change.")
+	attachCmd.Flags().StringVar(&attachArch, "arch", build.DefaultArch, fmt.Sprintf("The architecture the app was built for, which selects its debug build. One of %v", build.SupportedArchs))
+	rootCmd.AddCommand(attachCmd)
+}
+
+var attachCmd = &cobra.Command{
+	Use:   "attach",
+	Short: "Attach to a running debug app for hot-reload",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			return errors.New("does not take arguments")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		projectName := pubspec.GetPubSpec().Name
+		assertHoverInitialized()
+
+		if attachDebugURI != "" && cmd.Flags().Changed("observatory-port") {
+			log.Errorf("Only one of --debug-uri or --observatory-port can be set at one time")
+			os.Exit(1)
+		}
+		initDartParameters()
+		if err := build.ValidateArch(attachArch); err != nil {
+			log.Errorf("%v", err)
+			os.Exit(1)
+		}
+		if attachAssetDirectory == "" {
+			outputDirectoryPath, err := build.OutputDirectoryPath(runtime.GOOS, attachArch, build.DebugMode)
+			if err != nil {
+				log.Errorf("%v", err)
+				os.Exit(1)
+			}
+			attachAssetDirectory = filepath.Join(outputDirectoryPath, "flutter_assets")
+		}
+		err := attachAndReload(cmd.Context(), projectName)
+		if err != nil {
+			log.Errorf("%v", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// attachAndReload connects to the VM service of a running app and hot
+// reloads it on the commands read from the terminal, until the app exits.
+func attachAndReload(ctx context.Context, projectName string) error {
+	uri := attachDebugURI
+	if uri == "" {
+		uri = vmservice.ObservatoryURI(attachObservatoryPort)
+	}
+	client, err := vmservice.Dial(ctx, uri)
+	if err != nil {
+		if attachDebugURI == "" {
+			// the observatory of the apps built by `hover run` listens on
+			// another port when the configured one was in use
+			return errors.Wrapf(err, "no debug build of '%s' is listening on port %s. When the port is in use, the app falls back to a random port: pass the URI it prints after 'listening on' with --debug-uri", projectName, attachObservatoryPort)
+		}
+		return err
+	}
+	defer client.Close()
+	vm, err := client.GetVM(ctx)
+	if err != nil {
+		return err
+	}
+	log.Infof("Connected to the VM service at %s, running %d isolate(s)", uri, len(vm.Isolates))
+
+	reloader, err := startReloader(ctx, attachAssetDirectory)
+	if err != nil {
+		return errors.Wrap(err, "failed to start the dart compiler")
+	}
+	defer reloader.Close()
+	// the app is assumed to run the current sources, a hot restart
+	// loads them otherwise
+	_, err = reloader.Attach(ctx, client)
+	if err != nil {
+		return errors.Wrapf(err, "failed to attach to '%s'", projectName)
+	}
+
+	var dartChanges <-chan []string
+	if attachWatch {
+		dartChanges = watch.Poll(ctx, 500*time.Millisecond, []string{hotreload.SourceDirectory}, hotreload.DartSources)
+		log.Infof("Watching %s/ for changes", hotreload.SourceDirectory)
+	}
+	commands := readCommands(os.Stdin)
+	log.Infof("Connected hover to '%s' for hot reload, enter 'r' to hot reload, 'R' to hot restart, 'q' to detach", projectName)
+
+	for {
+		select {
+		case <-client.Done():
+			log.Infof("App '%s' exited.", projectName)
+			return nil
+		case <-ctx.Done():
+			return nil
+		case command, ok := <-commands:
+			if !ok {
+				// stdin was closed
+				commands = nil
+				continue
+			}
+			switch command {
+			case "r":
+				report, err := reloader.Reload(ctx)
+				reportReload("Hot reload", report, err)
+			case "R":
+				report, err := reloader.Restart(ctx)
+				reportReload("Hot restart", report, err)
+			case "q":
+				log.Infof("Detached from '%s', the app keeps running", projectName)
+				return nil
+			}
+		case paths := <-dartChanges:
+			log.Infof("%s changed, hot reloading", strings.Join(paths, ", "))
+			report, err := reloader.Reload(ctx)
+			reportReload("Hot reload", report, err)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-flutter-desktop/hover/internal/build"
+	"github.com/go-flutter-desktop/hover/internal/log"
+	"github.com/go-flutter-desktop/hover/internal/packaging"
+	"github.com/go-flutter-desktop/hover/internal/version"
+	"github.com/go-flutter-desktop/hover/internal/versioncheck"
+)
+
+var (
+	buildReportFormat string
+	buildReportPath   string
+)
+
+// buildReport collects the build report, it is nil when no report is
+// requested.
+var buildReport *build.Report
+
+// initBuildReport validates the `--report` flags and starts collecting the
+// build report.
+func initBuildReport() {
+	switch buildReportFormat {
+	case "":
+		return
+	case "json":
+		buildReport = &build.Report{}
+	default:
+		log.Errorf("Unsupported report format %q, only 'json' is supported", buildReportFormat)
+		os.Exit(1)
+	}
+}
+
+// writeBuildReport writes the report of the built targets, if a report is
+// requested.
+func writeBuildReport(results []buildResult) {
+	if buildReport == nil {
+		return
+	}
+	buildReport.HoverVersion = version.HoverVersion()
+	buildReport.Mode = buildOrRunMode.Name
+	buildReport.Arch = buildOrRunArch
+
+	absPath, err := filepath.Abs(build.BuildPath)
+	if err != nil {
+		log.Errorf("Failed to resolve absolute path of %s: %v", build.BuildPath, err)
+		os.Exit(1)
+	}
+	buildReport.GoFlutterVersion, err = versioncheck.CurrentGoFlutterTag(absPath)
+	if err != nil {
+		log.Errorf("%v", err)
+		os.Exit(1)
+	}
+
+	for _, result := range results {
+		target := build.ReportTarget{
+			Name:          result.target.name,
+			TargetOS:      result.target.targetOS,
+			EngineVersion: result.engineVersion,
+			Success:       result.err == nil,
+			Artifacts:     []build.ReportArtifact{},
+		}
+		if result.err != nil {
+			target.Error = result.err.Error()
+		} else {
+			artifactPath := result.artifact
+			if result.target.packagingTask == packaging.NoopTask {
+				// the artifact of an unpackaged build is the output directory,
+				// only report the executable.
+				artifactPath = outputBinaryPath(result.target.targetOS)
+			}
+			target.Artifacts, err = build.NewReportArtifacts(artifactPath)
+			if err != nil {
+				log.Errorf("%v", err)
+				os.Exit(1)
+			}
+		}
+		buildReport.Targets = append(buildReport.Targets, target)
+	}
+
+	err = buildReport.WriteJSON(buildReportPath)
+	if err != nil {
+		log.Errorf("%v", err)
+		os.Exit(1)
+	}
+	log.Infof("Build report written to %s", buildReportPath)
+}
+
+// dockerReportFlags returns the `--report` flags for the hover build running
+// inside the docker container, where the project is mounted at another path.
+func dockerReportFlags() []string {
+	if buildReportFormat == "" {
+		return nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		log.Errorf("Failed to get working dir: %v", err)
+		os.Exit(1)
+	}
+	absReportPath, err := filepath.Abs(buildReportPath)
+	if err != nil {
+		log.Errorf("Failed to resolve absolute path of %s: %v", buildReportPath, err)
+		os.Exit(1)
+	}
+	relReportPath, err := filepath.Rel(wd, absReportPath)
+	if err != nil || strings.HasPrefix(relReportPath, "..") {
+		log.Errorf("The report path must be inside the project when using --docker")
+		os.Exit(1)
+	}
+	return []string{"--report", buildReportFormat, "--report-path", filepath.ToSlash(relReportPath)}
+}
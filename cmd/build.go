@@ -1,39 +1,43 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/hashicorp/go-version"
-	"github.com/otiai10/copy"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
-	"github.com/go-flutter-desktop/hover/cmd/packaging"
 	"github.com/go-flutter-desktop/hover/internal/build"
 	"github.com/go-flutter-desktop/hover/internal/config"
-	"github.com/go-flutter-desktop/hover/internal/darwinhacks"
 	"github.com/go-flutter-desktop/hover/internal/enginecache"
 	"github.com/go-flutter-desktop/hover/internal/fileutils"
 	"github.com/go-flutter-desktop/hover/internal/log"
+	"github.com/go-flutter-desktop/hover/internal/packaging"
 	"github.com/go-flutter-desktop/hover/internal/pubspec"
 	internalVersion "github.com/go-flutter-desktop/hover/internal/version"
 	"github.com/go-flutter-desktop/hover/internal/versioncheck"
+	"github.com/go-flutter-desktop/hover/pkg/hover"
 )
 
-var dotSlash = string([]byte{'.', filepath.Separator})
-
 var (
 	// common build flags (shared with `hover run`)
 	buildOrRunFlutterTarget   string
+	buildOrRunArch            string
 	buildOrRunGoFlutterBranch string
 	buildOrRunCachePath       string
 	buildOrRunOpenGlVersion   string
 	buildOrRunEngineVersion   string
+	buildOrRunLocalEngineSrc  string
+	buildOrRunLocalEngine     string
 	buildOrRunHoverFlavor     string
+	buildOrRunDartDefines     []string
+	buildOrRunDartDefineFiles []string
 	buildOrRunDocker          bool
 	buildOrRunDebug           bool
 	buildOrRunJitRelease      bool
@@ -42,15 +46,27 @@ var (
 	buildOrRunMode            build.Mode
 	buildOrRunSkipFlutter     bool
 	buildOrRunSkipEmbedder    bool
+	buildOrRunForce           bool
 )
 
-func initCompileFlags(cmd *cobra.Command) {
+// initDartFlags adds the flags of the dart compilation, shared by the
+// commands building the app and `hover attach`.
+func initDartFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().StringVarP(&buildOrRunFlutterTarget, "target", "t", config.BuildTargetDefault, "The main entry-point file of the application.")
+	cmd.PersistentFlags().StringVar(&buildOrRunHoverFlavor, "flavor", "", "The flavor to use, defaults to 'hover.yaml'.")
+	cmd.PersistentFlags().StringArrayVar(&buildOrRunDartDefines, "dart-define", nil, "A KEY=VALUE compile-time constant of the dart code, read with String.fromEnvironment. Can be repeated.")
+	cmd.PersistentFlags().StringArrayVar(&buildOrRunDartDefineFiles, "dart-define-from-file", nil, "A JSON or .env file of compile-time constants of the dart code. Can be repeated.")
+}
+
+func initCompileFlags(cmd *cobra.Command) {
+	initDartFlags(cmd)
+	cmd.PersistentFlags().StringVar(&buildOrRunArch, "arch", build.DefaultArch, fmt.Sprintf("The architecture to build for. One of %v", build.SupportedArchs))
 	cmd.PersistentFlags().StringVarP(&buildOrRunGoFlutterBranch, "branch", "b", "", "The 'go-flutter' version to use. (@master or @v0.20.0 for example)")
 	cmd.PersistentFlags().StringVar(&buildOrRunCachePath, "cache-path", enginecache.DefaultCachePath(), "The path that hover uses to cache dependencies such as the Flutter engine .so/.dll")
 	cmd.PersistentFlags().StringVar(&buildOrRunOpenGlVersion, "opengl", config.BuildOpenGlVersionDefault, "The OpenGL version specified here is only relevant for external texture plugin (i.e. video_plugin).\nIf 'none' is provided, texture won't be supported. Note: the Flutter Engine still needs a OpenGL compatible context.")
 	cmd.PersistentFlags().StringVar(&buildOrRunEngineVersion, "engine-version", config.BuildEngineDefault, "The flutter engine version to use.")
-	cmd.PersistentFlags().StringVar(&buildOrRunHoverFlavor, "flavor", "", "The flavor to use, defaults to 'hover.yaml'.")
+	cmd.PersistentFlags().StringVar(&buildOrRunLocalEngineSrc, "local-engine-src-path", "", "The src directory of a flutter engine checkout, defaults to $FLUTTER_ENGINE.")
+	cmd.PersistentFlags().StringVar(&buildOrRunLocalEngine, "local-engine", "", "The name of a local engine build in <local-engine-src-path>/out to use instead of a downloaded engine, e.g. host_release.")
 	cmd.PersistentFlags().BoolVar(&buildOrRunDocker, "docker", false, "Execute the go build and packaging in a docker container. The Flutter build is always run locally")
 	cmd.PersistentFlags().BoolVar(&buildOrRunDebug, "debug", false, "Build a debug version of the app.")
 	cmd.PersistentFlags().BoolVar(&buildOrRunJitRelease, "jit-release", false, "Build a debug version of the app without the terminal windows on Windows.")
@@ -58,6 +74,7 @@ func initCompileFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().BoolVar(&buildOrRunProfile, "profile", false, "Build a profile version of the app. Currently very experimental")
 	cmd.PersistentFlags().BoolVar(&buildOrRunSkipFlutter, "skip-flutter", false, "Skip the flutter steps")
 	cmd.PersistentFlags().BoolVar(&buildOrRunSkipEmbedder, "skip-embedder", false, "Skip the flutter steps")
+	cmd.PersistentFlags().BoolVar(&buildOrRunForce, "force", false, "Rebuild the flutter bundle and the go binary even if their inputs didn't change")
 
 	cmd.PersistentFlags().MarkHidden("branch")
 }
@@ -67,19 +84,24 @@ var (
 	buildVersionNumber      string
 	buildSkipEngineDownload bool
 	buildIgnoreHostOS       bool
+	buildAll                bool
+	buildObfuscate          bool
+	buildSplitDebugInfo     string
+	buildReproducible       bool
 )
 
-const mingwGccBinName = "x86_64-w64-mingw32-gcc"
-const clangBinName = "o32-clang"
-
-var engineCachePath string
-
 func init() {
 	initCompileFlags(buildCmd)
 
 	buildCmd.PersistentFlags().StringVar(&buildVersionNumber, "version-number", "", "Override the version number used in build and packaging. You may use it with $(git describe --tags)")
 	buildCmd.PersistentFlags().BoolVar(&buildSkipEngineDownload, "skip-engine-download", false, "Skip downloading the Flutter Engine.")
+	buildCmd.Flags().BoolVar(&buildAll, "all", false, "Build every packaging format that has been initialized.")
+	buildCmd.PersistentFlags().StringVar(&buildReportFormat, "report", "", "Write a report of the build, with the artifacts and their checksums. Only 'json' is supported.")
+	buildCmd.PersistentFlags().StringVar(&buildReportPath, "report-path", filepath.Join(build.BuildPath, "build", "report.json"), "The path of the build report written by --report")
 	buildCmd.PersistentFlags().BoolVar(&buildIgnoreHostOS, "ignore-host-os", false, "Ignore the host OS for AOT builds")
+	buildCmd.PersistentFlags().BoolVar(&buildObfuscate, "obfuscate", false, "Obfuscate the dart symbols of release and profile builds. Requires --split-debug-info")
+	buildCmd.PersistentFlags().BoolVar(&buildReproducible, "reproducible", false, "Build identical binaries and packages from the same sources. Timestamps are taken from $SOURCE_DATE_EPOCH, or the last git commit")
+	buildCmd.PersistentFlags().StringVar(&buildSplitDebugInfo, "split-debug-info", "", "Save the debugging information of release and profile builds in this directory, in a subdirectory per version, e.g. build/symbols")
 
 	buildCmd.PersistentFlags().MarkHidden("ignore-host-os")
 
@@ -101,167 +123,387 @@ func init() {
 var buildCmd = &cobra.Command{
 	Use:   "build",
 	Short: "Build a desktop release",
+	Long: "Build a desktop release.\n" +
+		"Multiple targets can be built at once, for example: `hover build linux-deb linux-rpm windows-msi`.\n" +
+		"The flutter bundle is built once and the go binary once per OS.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			return errors.Errorf("unknown build target %q", args[0])
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if !buildAll {
+			cmd.Help()
+			return
+		}
+		subcommandBuildTargets(cmd.Context(), initializedBuildTargetNames())
+	},
 }
 
 var buildLinuxCmd = &cobra.Command{
 	Use:   "linux",
 	Short: "Build a desktop release for linux",
+	Args:  buildTargetArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		initBuildParameters("linux", build.ReleaseMode)
-		subcommandBuild("linux", packaging.NoopTask, nil)
+		subcommandBuildTargets(cmd.Context(), append([]string{"linux"}, args...))
 	},
 }
 
 var buildLinuxSnapCmd = &cobra.Command{
 	Use:   "linux-snap",
 	Short: "Build a desktop release for linux and package it for snap",
+	Args:  buildTargetArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		initBuildParameters("linux", build.ReleaseMode)
-		subcommandBuild("linux", packaging.LinuxSnapTask, nil)
+		subcommandBuildTargets(cmd.Context(), append([]string{"linux-snap"}, args...))
 	},
 }
 
 var buildLinuxDebCmd = &cobra.Command{
 	Use:   "linux-deb",
 	Short: "Build a desktop release for linux and package it for deb",
+	Args:  buildTargetArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		initBuildParameters("linux", build.ReleaseMode)
-		subcommandBuild("linux", packaging.LinuxDebTask, nil)
+		subcommandBuildTargets(cmd.Context(), append([]string{"linux-deb"}, args...))
 	},
 }
 
 var buildLinuxAppImageCmd = &cobra.Command{
 	Use:   "linux-appimage",
 	Short: "Build a desktop release for linux and package it for AppImage",
+	Args:  buildTargetArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		initBuildParameters("linux", build.ReleaseMode)
-		subcommandBuild("linux", packaging.LinuxAppImageTask, nil)
+		subcommandBuildTargets(cmd.Context(), append([]string{"linux-appimage"}, args...))
 	},
 }
 
 var buildLinuxRpmCmd = &cobra.Command{
 	Use:   "linux-rpm",
 	Short: "Build a desktop release for linux and package it for rpm",
+	Args:  buildTargetArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		initBuildParameters("linux", build.ReleaseMode)
-		subcommandBuild("linux", packaging.LinuxRpmTask, nil)
+		subcommandBuildTargets(cmd.Context(), append([]string{"linux-rpm"}, args...))
 	},
 }
 
 var buildLinuxPkgCmd = &cobra.Command{
 	Use:   "linux-pkg",
 	Short: "Build a desktop release for linux and package it for pacman pkg",
+	Args:  buildTargetArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		initBuildParameters("linux", build.ReleaseMode)
-		subcommandBuild("linux", packaging.LinuxPkgTask, nil)
+		subcommandBuildTargets(cmd.Context(), append([]string{"linux-pkg"}, args...))
 	},
 }
 
 var buildDarwinCmd = &cobra.Command{
 	Use:   "darwin",
 	Short: "Build a desktop release for darwin",
+	Args:  buildTargetArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		initBuildParameters("darwin", build.ReleaseMode)
-		subcommandBuild("darwin", packaging.NoopTask, nil)
+		subcommandBuildTargets(cmd.Context(), append([]string{"darwin"}, args...))
 	},
 }
 
 var buildDarwinBundleCmd = &cobra.Command{
 	Use:   "darwin-bundle",
 	Short: "Build a desktop release for darwin and package it for OSX bundle",
+	Args:  buildTargetArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		initBuildParameters("darwin", build.ReleaseMode)
-		subcommandBuild("darwin", packaging.DarwinBundleTask, nil)
+		subcommandBuildTargets(cmd.Context(), append([]string{"darwin-bundle"}, args...))
 	},
 }
 
 var buildDarwinPkgCmd = &cobra.Command{
 	Use:   "darwin-pkg",
 	Short: "Build a desktop release for darwin and package it for OSX pkg installer",
+	Args:  buildTargetArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		initBuildParameters("darwin", build.ReleaseMode)
-		subcommandBuild("darwin", packaging.DarwinPkgTask, nil)
+		subcommandBuildTargets(cmd.Context(), append([]string{"darwin-pkg"}, args...))
 	},
 }
 
 var buildDarwinDmgCmd = &cobra.Command{
 	Use:   "darwin-dmg",
 	Short: "Build a desktop release for darwin and package it for OSX dmg",
+	Args:  buildTargetArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		initBuildParameters("darwin", build.ReleaseMode)
-		subcommandBuild("darwin", packaging.DarwinDmgTask, nil)
+		subcommandBuildTargets(cmd.Context(), append([]string{"darwin-dmg"}, args...))
 	},
 }
 
 var buildWindowsCmd = &cobra.Command{
 	Use:   "windows",
 	Short: "Build a desktop release for windows",
+	Args:  buildTargetArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		initBuildParameters("windows", build.ReleaseMode)
-		subcommandBuild("windows", packaging.NoopTask, nil)
+		subcommandBuildTargets(cmd.Context(), append([]string{"windows"}, args...))
 	},
 }
 
 var buildWindowsMsiCmd = &cobra.Command{
 	Use:   "windows-msi",
 	Short: "Build a desktop release for windows and package it for msi",
+	Args:  buildTargetArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		initBuildParameters("windows", build.ReleaseMode)
-		subcommandBuild("windows", packaging.WindowsMsiTask, nil)
+		subcommandBuildTargets(cmd.Context(), append([]string{"windows-msi"}, args...))
 	},
 }
 
-// TODO: replace targetOS with a same Task type for build (build.Task) ?
-func subcommandBuild(targetOS string, packagingTask packaging.Task, vmArguments []string) {
-	assertHoverInitialized()
-	packagingTask.AssertInitialized()
-	if !buildOrRunDocker {
-		packagingTask.AssertSupported()
+// buildTarget is a target that can be given to `hover build`
+type buildTarget struct {
+	name          string
+	targetOS      string
+	packagingTask packaging.Task
+}
+
+// buildTargets lists all targets supported by `hover build`, in the order
+// used by `hover build --all`.
+var buildTargets = []buildTarget{
+	{name: "linux", targetOS: "linux", packagingTask: packaging.NoopTask},
+	{name: "linux-snap", targetOS: "linux", packagingTask: packaging.LinuxSnapTask},
+	{name: "linux-deb", targetOS: "linux", packagingTask: packaging.LinuxDebTask},
+	{name: "linux-appimage", targetOS: "linux", packagingTask: packaging.LinuxAppImageTask},
+	{name: "linux-rpm", targetOS: "linux", packagingTask: packaging.LinuxRpmTask},
+	{name: "linux-pkg", targetOS: "linux", packagingTask: packaging.LinuxPkgTask},
+	{name: "darwin", targetOS: "darwin", packagingTask: packaging.NoopTask},
+	{name: "darwin-bundle", targetOS: "darwin", packagingTask: packaging.DarwinBundleTask},
+	{name: "darwin-pkg", targetOS: "darwin", packagingTask: packaging.DarwinPkgTask},
+	{name: "darwin-dmg", targetOS: "darwin", packagingTask: packaging.DarwinDmgTask},
+	{name: "windows", targetOS: "windows", packagingTask: packaging.NoopTask},
+	{name: "windows-msi", targetOS: "windows", packagingTask: packaging.WindowsMsiTask},
+}
+
+func findBuildTarget(name string) (buildTarget, bool) {
+	for _, target := range buildTargets {
+		if target.name == name {
+			return target, true
+		}
 	}
-	if buildOrRunDocker {
-		removeBrokenBundleFilesForDocker()
-		var buildFlags []string
-		buildFlags = append(buildFlags, commonFlags()...)
-		buildFlags = append(buildFlags, "--skip-engine-download")
-		if buildOrRunSkipFlutter {
-			buildFlags = append(buildFlags, "--skip-flutter")
+	return buildTarget{}, false
+}
+
+// buildTargetArgs validates the additional targets given to a build subcommand.
+func buildTargetArgs(cmd *cobra.Command, args []string) error {
+	for _, arg := range args {
+		if _, ok := findBuildTarget(arg); !ok {
+			return errors.Errorf("unknown build target %q", arg)
 		}
-		if buildOrRunSkipEmbedder {
-			buildFlags = append(buildFlags, "--skip-embedder")
+	}
+	return nil
+}
+
+// initializedBuildTargetNames returns the names of the targets built by
+// `hover build --all`: every packaging format that has been initialized.
+func initializedBuildTargetNames() []string {
+	var names []string
+	for _, target := range buildTargets {
+		if target.packagingTask != packaging.NoopTask && target.packagingTask.IsInitialized() {
+			names = append(names, target.name)
 		}
-		if buildVersionNumber != "" {
-			buildFlags = append(buildFlags, "--version-number", buildVersionNumber)
+	}
+	if len(names) == 0 {
+		log.Errorf("No packaging format has been initialized. Use `%s` first.", log.Au().Magenta("hover init-packaging"))
+		os.Exit(1)
+	}
+	return names
+}
+
+// buildResult is the outcome of building a single target.
+type buildResult struct {
+	target        buildTarget
+	artifact      string
+	engineVersion string
+	err           error
+}
+
+// subcommandBuildTargets builds multiple targets. The flutter bundle is built
+// once, the go binary once per OS, then all packaging tasks of an OS are run
+// on the shared output.
+func subcommandBuildTargets(ctx context.Context, targetNames []string) {
+	assertHoverInitialized()
+	initBuildReport()
+
+	var targetOSs []string
+	targetsByOS := make(map[string][]buildTarget)
+	for _, name := range targetNames {
+		target, ok := findBuildTarget(name)
+		if !ok {
+			log.Errorf("Unknown build target %s", name)
+			os.Exit(1)
 		}
-		if buildOrRunDebug {
-			buildFlags = append(buildFlags, "--debug")
+		if _, ok := targetsByOS[target.targetOS]; !ok {
+			targetOSs = append(targetOSs, target.targetOS)
 		}
-		if buildOrRunJitRelease {
-			buildFlags = append(buildFlags, "--jit-release")
+		duplicate := false
+		for _, t := range targetsByOS[target.targetOS] {
+			duplicate = duplicate || t.name == target.name
 		}
-		if buildOrRunRelease {
-			buildFlags = append(buildFlags, "--release")
+		if !duplicate {
+			targetsByOS[target.targetOS] = append(targetsByOS[target.targetOS], target)
 		}
-		if buildOrRunProfile {
-			buildFlags = append(buildFlags, "--profile")
+	}
+	for _, targetOS := range targetOSs {
+		for _, target := range targetsByOS[targetOS] {
+			assertPackagingTaskUsable(target.packagingTask)
+		}
+	}
+
+	if buildOrRunDocker {
+		for _, targetOS := range targetOSs {
+			initBuildParameters(targetOS, build.ReleaseMode)
+			validateDockerBuild(targetOS)
+			prepareEngine(ctx, targetOS)
 		}
-		dockerHoverBuild(targetOS, packagingTask, buildFlags, nil)
 		removeBrokenBundleFilesForDocker()
-	} else {
-		if !buildOrRunSkipFlutter {
-			cleanBuildOutputsDir(targetOS)
-			buildFlutterBundle(targetOS)
+		dockerHoverBuild(ctx, targetNames, append(dockerBuildFlags(), dockerReportFlags()...), nil)
+		removeBrokenBundleFilesForDocker()
+		return
+	}
+
+	var results []buildResult
+	var bundleOS string
+	for _, targetOS := range targetOSs {
+		initBuildParameters(targetOS, build.ReleaseMode)
+		opts := buildOptions(targetOS, nil)
+		opts.FlutterBundleOS = bundleOS
+		err := buildApp(ctx, opts)
+		if err != nil {
+			log.Errorf("Building app for %s failed: %v", targetOS, err)
+		} else if bundleOS == "" && !buildOrRunSkipFlutter {
+			bundleOS = targetOS
+		}
+		for _, target := range targetsByOS[targetOS] {
+			result := buildResult{target: target, engineVersion: hover.CachedEngineVersion(ctx, buildOptions(targetOS, nil))}
+			if err != nil {
+				// the targets of the OS can't be packaged without its build
+				result.err = err
+			} else if target.packagingTask == packaging.NoopTask {
+				result.artifact = outputDirectoryPath(targetOS)
+			} else {
+				log.Infof("Packaging app for %s", target.packagingTask.Name())
+				result.artifact, result.err = hover.Package(ctx, target.packagingTask, buildOptions(targetOS, nil))
+				if result.err != nil {
+					log.Errorf("Packaging app for %s failed: %v", target.packagingTask.Name(), result.err)
+				} else {
+					log.Infof("Successfully packaged app for %s", target.packagingTask.Name())
+				}
+			}
+			results = append(results, result)
+		}
+	}
+
+	writeBuildReport(results)
+	if !printBuildSummary(results) {
+		os.Exit(1)
+	}
+}
+
+// printBuildSummary prints a table of the built artifacts, it returns false
+// when one of the targets failed.
+func printBuildSummary(results []buildResult) bool {
+	success := true
+	log.Infof("Build summary:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "TARGET\tSTATUS\tARTIFACT")
+	for _, result := range results {
+		status := "ok"
+		if result.err != nil {
+			status = "failed"
+			success = false
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", result.target.name, status, result.artifact)
+	}
+	w.Flush()
+	return success
+}
+
+// subcommandBuild builds the app for a single targetOS and packaging task.
+func subcommandBuild(ctx context.Context, targetOS string, packagingTask packaging.Task, vmArguments []string) {
+	assertHoverInitialized()
+	assertPackagingTaskUsable(packagingTask)
+	if buildOrRunDocker {
+		validateDockerBuild(targetOS)
+		prepareEngine(ctx, targetOS)
+		removeBrokenBundleFilesForDocker()
+		targetOSAndPackaging := targetOS
+		if packName := packagingTask.Name(); packName != "" {
+			targetOSAndPackaging = packName
 		}
-		if !buildOrRunSkipEmbedder {
-			buildGoBinary(targetOS, vmArguments)
+		dockerHoverBuild(ctx, []string{targetOSAndPackaging}, dockerBuildFlags(), vmArguments)
+		removeBrokenBundleFilesForDocker()
+	} else {
+		err := buildApp(ctx, buildOptions(targetOS, vmArguments))
+		if err != nil {
+			log.Errorf("%v", err)
+			os.Exit(1)
 		}
 		if packagingTask != packaging.NoopTask {
 			log.Infof("Packaging app for %s", packagingTask.Name())
-			packagingTask.Pack(buildVersionNumber, buildOrRunMode)
+			_, err := hover.Package(ctx, packagingTask, buildOptions(targetOS, vmArguments))
+			if err != nil {
+				log.Errorf("%v", err)
+				os.Exit(1)
+			}
 			log.Infof("Successfully packaged app for %s", packagingTask.Name())
 		}
 	}
 }
 
+// assertPackagingTaskUsable exits when the packaging task isn't initialized
+// or when its tools are missing from the host.
+func assertPackagingTaskUsable(packagingTask packaging.Task) {
+	err := packagingTask.AssertInitialized()
+	if err == nil && !buildOrRunDocker {
+		err = packagingTask.AssertSupported()
+	}
+	if err != nil {
+		log.Errorf("%v", err)
+		os.Exit(1)
+	}
+}
+
+// dockerBuildFlags returns the flags passed to the hover build running inside
+// the docker container.
+func dockerBuildFlags() []string {
+	var buildFlags []string
+	buildFlags = append(buildFlags, commonFlags()...)
+	buildFlags = append(buildFlags, "--skip-engine-download")
+	if buildOrRunSkipFlutter {
+		buildFlags = append(buildFlags, "--skip-flutter")
+	}
+	if buildOrRunSkipEmbedder {
+		buildFlags = append(buildFlags, "--skip-embedder")
+	}
+	if buildOrRunForce {
+		buildFlags = append(buildFlags, "--force")
+	}
+	if buildVersionNumber != "" {
+		buildFlags = append(buildFlags, "--version-number", buildVersionNumber)
+	}
+	if buildObfuscate {
+		buildFlags = append(buildFlags, "--obfuscate")
+	}
+	if buildReproducible {
+		buildFlags = append(buildFlags, "--reproducible")
+	}
+	if buildSplitDebugInfo != "" {
+		buildFlags = append(buildFlags, "--split-debug-info", filepath.ToSlash(buildSplitDebugInfo))
+	}
+	if buildOrRunDebug {
+		buildFlags = append(buildFlags, "--debug")
+	}
+	if buildOrRunJitRelease {
+		buildFlags = append(buildFlags, "--jit-release")
+	}
+	if buildOrRunRelease {
+		buildFlags = append(buildFlags, "--release")
+	}
+	if buildOrRunProfile {
+		buildFlags = append(buildFlags, "--profile")
+	}
+	return buildFlags
+}
+
 // removeBrokenBundleFilesForDocker removes some files, because they don't work in the container or after something ran in the container
 func removeBrokenBundleFilesForDocker() {
 	for _, file := range []string{".packages", ".dart_tool"} {
@@ -275,6 +517,24 @@ func removeBrokenBundleFilesForDocker() {
 	}
 }
 
+// initDartParameters loads the hover.yaml of the flavor and sets the flutter
+// target from it, unless it was set by the flags.
+func initDartParameters() {
+	// hover.yaml file needs to be set before accessing config.GetConfig()
+	if buildOrRunHoverFlavor != "" {
+		err := config.SetHoverFlavor(buildOrRunHoverFlavor)
+		if err != nil {
+			log.Errorf("%v", err)
+			os.Exit(1)
+		}
+	}
+	assertHoverConfigLoaded()
+
+	if buildOrRunFlutterTarget == config.BuildTargetDefault && config.GetConfig().Target != "" {
+		buildOrRunFlutterTarget = config.GetConfig().Target
+	}
+}
+
 // initBuildParameters is used to initialize all the build parameters. It sets
 // fallback values based on config or defaults for values that have not
 // explicitly been set through flags.
@@ -284,10 +544,7 @@ func initBuildParameters(targetOS string, defaultBuildOrRunMode build.Mode) {
 		os.Exit(1)
 	}
 
-	// hover.yaml file needs to be set before accessing config.GetConfig()
-	if buildOrRunHoverFlavor != "" {
-		config.SetHoverFlavor(buildOrRunHoverFlavor)
-	}
+	initDartParameters()
 
 	if buildOrRunEngineVersion == config.BuildEngineDefault && config.GetConfig().Engine != "" {
 		log.Warnf("changing the engine version can lead to undesirable behavior")
@@ -335,43 +592,35 @@ func initBuildParameters(targetOS string, defaultBuildOrRunMode build.Mode) {
 		buildOrRunMode = build.ProfileMode
 	}
 
-	validateBuildParameters(targetOS)
-
-	engineCachePath = enginecache.EngineCachePath(targetOS, buildOrRunCachePath, buildOrRunMode)
-	if !buildSkipEngineDownload {
-		enginecache.ValidateOrUpdateEngine(targetOS, buildOrRunCachePath, buildOrRunEngineVersion, buildOrRunMode)
+	if err := build.ValidateArch(buildOrRunArch); err != nil {
+		log.Errorf("%v", err)
+		os.Exit(1)
 	}
 }
 
-func validateBuildParameters(targetOS string) {
-	if buildOrRunMode.IsAot && targetOS != runtime.GOOS && !buildIgnoreHostOS {
-		if targetOS == "windows" && runtime.GOOS != targetOS {
-			if path, err := exec.LookPath("wine"); (err != nil || len(path) == 0) && !buildOrRunDocker {
-				// Skip checking for wine if using docker, but still being on host system
-				log.Errorf("To cross-compile AOT apps for windows on %s install wine from your package manager or https://www.winehq.org/ or use the `--docker` flag", runtime.GOOS)
-				os.Exit(1)
-			}
-		} else if targetOS == "darwin" && runtime.GOOS == "linux" {
-			if buildOrRunDocker {
-				// Darling doesn't work in a docker container so it should fail when trying to use docker
-				log.Errorf("It is not possible to cross-compile AOT apps for darwin using docker")
-				log.Errorf("To cross-compile AOT apps for darwin on %s install darling from your package manager or https://www.darlinghq.org/", runtime.GOOS)
-				os.Exit(1)
-			} else if path, err := exec.LookPath("darling"); err != nil || len(path) == 0 {
-				log.Errorf("To cross-compile AOT apps for darwin on %s install darling from your package manager or https://www.darlinghq.org/", runtime.GOOS)
-				os.Exit(1)
-			}
-		} else if targetOS == "linux" {
-			if !buildOrRunDocker {
-				log.Errorf("To cross-compile AOT apps for linux on %s use the `--docker` flag", runtime.GOOS)
-				os.Exit(1)
-			}
-		} else {
-			log.Errorf("AOT builds currently only work on their host OS")
-			log.Errorf("Use the JIT release mode using the `--jit-release` flag instead")
+// validateDockerBuild exits when targetOS can't be built in the docker
+// container. The other build parameters are validated inside the container.
+func validateDockerBuild(targetOS string) {
+	if buildOrRunLocalEngine != "" {
+		log.Errorf("A local engine can't be used in a docker build")
+		os.Exit(1)
+	}
+	if filepath.IsAbs(buildSplitDebugInfo) {
+		log.Errorf("The --split-debug-info directory of a docker build must be relative to the project directory")
+		os.Exit(1)
+	}
+	for _, file := range buildOrRunDartDefineFiles {
+		if filepath.IsAbs(file) {
+			log.Errorf("The --dart-define-from-file files of a docker build must be relative to the project directory")
 			os.Exit(1)
 		}
 	}
+	if buildOrRunMode.IsAot && targetOS == "darwin" && runtime.GOOS != targetOS {
+		// Darling doesn't work in a docker container so it should fail when trying to use docker
+		log.Errorf("It is not possible to cross-compile AOT apps for darwin using docker")
+		log.Errorf("To cross-compile AOT apps for darwin on %s install darling from your package manager or https://www.darlinghq.org/", runtime.GOOS)
+		os.Exit(1)
+	}
 }
 
 func commonFlags() []string {
@@ -379,6 +628,9 @@ func commonFlags() []string {
 	if buildOrRunFlutterTarget != config.BuildTargetDefault {
 		f = append(f, "--target", buildOrRunFlutterTarget)
 	}
+	if buildOrRunArch != build.DefaultArch {
+		f = append(f, "--arch", buildOrRunArch)
+	}
 	if buildOrRunGoFlutterBranch != "" {
 		f = append(f, "--branch", buildOrRunGoFlutterBranch)
 	}
@@ -388,6 +640,12 @@ func commonFlags() []string {
 	if buildOrRunHoverFlavor != "" {
 		f = append(f, "--flavor", buildOrRunHoverFlavor)
 	}
+	for _, define := range buildOrRunDartDefines {
+		f = append(f, "--dart-define", define)
+	}
+	for _, file := range buildOrRunDartDefineFiles {
+		f = append(f, "--dart-define-from-file", filepath.ToSlash(file))
+	}
 	return f
 }
 
@@ -400,7 +658,11 @@ func assertTargetFileExists(targetFilename string) {
 		if targetFilename == config.BuildTargetDefault {
 			log.Warnf("Let hover add the \"lib/main_desktop.dart\" file? ")
 			if askForConfirmation() {
-				fileutils.CopyAsset("app/main_desktop.dart", filepath.Join("lib", "main_desktop.dart"))
+				err := fileutils.CopyAsset("app/main_desktop.dart", filepath.Join("lib", "main_desktop.dart"))
+				if err != nil {
+					log.Errorf("%v", err)
+					os.Exit(1)
+				}
 				log.Infof("Target file \"lib/main_desktop.dart\" has been created.")
 				log.Infof("       Depending on your project, you might want to tweak it.")
 				return
@@ -415,200 +677,125 @@ func assertTargetFileExists(targetFilename string) {
 	}
 }
 
-func cleanBuildOutputsDir(targetOS string) {
-	err := os.RemoveAll(build.OutputDirectoryPath(targetOS, buildOrRunMode))
-	log.Printf("Cleaning the build directory")
+// outputDirectoryPath returns the output directory of targetOS for the
+// architecture and mode being built.
+func outputDirectoryPath(targetOS string) string {
+	outputDirectoryPath, err := build.OutputDirectoryPath(targetOS, buildOrRunArch, buildOrRunMode)
 	if err != nil {
-		log.Errorf("Failed to remove output directory %s: %v", build.OutputDirectoryPath(targetOS, buildOrRunMode), err)
+		log.Errorf("%v", err)
 		os.Exit(1)
 	}
-	err = os.MkdirAll(build.OutputDirectoryPath(targetOS, buildOrRunMode), 0775)
+	return outputDirectoryPath
+}
+
+// intermediatesDirectoryPath returns the intermediates directory of targetOS
+// for the architecture and mode being built.
+func intermediatesDirectoryPath(targetOS string) string {
+	intermediatesDirectoryPath, err := build.IntermediatesDirectoryPath(targetOS, buildOrRunArch, buildOrRunMode)
 	if err != nil {
-		log.Errorf("Failed to create output directory %s: %v", build.OutputDirectoryPath(targetOS, buildOrRunMode), err)
+		log.Errorf("%v", err)
 		os.Exit(1)
 	}
+	return intermediatesDirectoryPath
 }
 
-func buildFlutterBundle(targetOS string) {
-	if buildOrRunFlutterTarget == config.BuildTargetDefault && config.GetConfig().Target != "" {
-		buildOrRunFlutterTarget = config.GetConfig().Target
-	}
-	assertTargetFileExists(buildOrRunFlutterTarget)
+// outputBinaryPath returns the path of the app executable built for targetOS.
+func outputBinaryPath(targetOS string) string {
+	return filepath.Join(outputDirectoryPath(targetOS), build.OutputBinary(config.GetConfig().GetExecutableName(pubspec.GetPubSpec().Name), targetOS))
+}
 
-	runPluginGet, err := shouldRunPluginGet()
-	if err != nil {
-		log.Errorf("Failed to check if plugin get should be run: %v.\n", err)
-		os.Exit(1)
-	}
-	if runPluginGet {
-		log.Printf("listing available plugins:")
-		if hoverPluginGet(true) {
-			// TODO: change this so that it only logs when there are plugins missing..
-			log.Infof(fmt.Sprintf("Run `%s` to update plugins", log.Au().Magenta("hover plugins get")))
-		}
+// buildOptions returns the options of the build of targetOS set by the flags.
+func buildOptions(targetOS string, vmArguments []string) hover.BuildOptions {
+	return hover.BuildOptions{
+		TargetOS:           targetOS,
+		Arch:               buildOrRunArch,
+		Mode:               buildOrRunMode,
+		FlutterTarget:      buildOrRunFlutterTarget,
+		Flavor:             buildOrRunHoverFlavor,
+		CachePath:          buildOrRunCachePath,
+		EngineVersion:      buildOrRunEngineVersion,
+		EngineMirror:       engineMirror(),
+		LocalEngineSrcPath: buildOrRunLocalEngineSrc,
+		LocalEngine:        buildOrRunLocalEngine,
+		OpenGlVersion:      buildOrRunOpenGlVersion,
+		VersionNumber:      buildVersionNumber,
+		DartDefines:        buildOrRunDartDefines,
+		DartDefineFiles:    buildOrRunDartDefineFiles,
+		VMArguments:        vmArguments,
+		Obfuscate:          buildObfuscate,
+		SplitDebugInfo:     buildSplitDebugInfo,
+		SkipFlutter:        buildOrRunSkipFlutter,
+		SkipEmbedder:       buildOrRunSkipEmbedder,
+		SkipEngineDownload: buildSkipEngineDownload,
+		Force:              buildOrRunForce,
+		IgnoreHostOS:       buildIgnoreHostOS,
+		Reproducible:       buildReproducible,
+		Report:             buildReport,
 	}
+}
 
-	checkFlutterChannel()
-	var trackWidgetCreation string
-	if buildOrRunMode == build.DebugMode {
-		trackWidgetCreation = "--track-widget-creation"
+// engineMirror returns the engine mirror set by the HOVER_ENGINE_MIRROR
+// environment variable, or otherwise by hover.yaml.
+func engineMirror() string {
+	if mirror := os.Getenv(enginecache.MirrorEnv); mirror != "" {
+		return mirror
 	}
+	return config.GetConfig().EngineMirror
+}
 
-	cmdFlutterBuild := exec.Command(build.FlutterBin(), "build", "bundle",
-		"--asset-dir", filepath.Join(build.OutputDirectoryPath(targetOS, buildOrRunMode), "flutter_assets"),
-		"--target", buildOrRunFlutterTarget,
-		trackWidgetCreation,
-	)
-	cmdFlutterBuild.Stderr = os.Stderr
-	cmdFlutterBuild.Stdout = os.Stdout
-
-	log.Infof("Bundling flutter app")
-	err = cmdFlutterBuild.Run()
+// prepareEngine downloads the engine of targetOS, unless
+// `--skip-engine-download` is used.
+func prepareEngine(ctx context.Context, targetOS string) {
+	if buildSkipEngineDownload {
+		return
+	}
+	err := hover.PrepareEngine(ctx, buildOptions(targetOS, nil))
 	if err != nil {
-		log.Errorf("Flutter build failed: %v", err)
+		log.Errorf("%v", err)
 		os.Exit(1)
 	}
-	if buildOrRunMode.IsAot {
-		err := os.Remove(filepath.Join(build.OutputDirectoryPath(targetOS, buildOrRunMode), "flutter_assets", "isolate_snapshot_data"))
-		if err != nil {
-			log.Errorf("Failed to remove unused isolate_snapshot_data: %v", err)
-			os.Exit(1)
-		}
-		err = os.Remove(filepath.Join(build.OutputDirectoryPath(targetOS, buildOrRunMode), "flutter_assets", "vm_snapshot_data"))
-		if err != nil {
-			log.Errorf("Failed to remove unused vm_snapshot_data: %v", err)
-			os.Exit(1)
-		}
-		err = os.Remove(filepath.Join(build.OutputDirectoryPath(targetOS, buildOrRunMode), "flutter_assets", "kernel_blob.bin"))
-		if err != nil {
-			log.Errorf("Failed to remove unused kernel_blob.bin: %v", err)
-			os.Exit(1)
-		}
-
-		useWine := targetOS == "windows" && runtime.GOOS != targetOS
-		useDarling := targetOS == "darwin" && runtime.GOOS != targetOS
-
-		if useDarling {
-			darwinhacks.ChangePackagesFilePath(true)
-		}
-
-		dart := filepath.Join(engineCachePath, "dart"+build.ExecutableExtension(targetOS))
-		genSnapshot := filepath.Join(engineCachePath, "gen_snapshot"+build.ExecutableExtension(targetOS))
-		kernelSnapshot := filepath.Join(build.OutputDirectoryPath(targetOS, buildOrRunMode), "kernel_snapshot.dill")
-		elfSnapshot := filepath.Join(build.OutputDirectoryPath(targetOS, buildOrRunMode), "libapp.so")
-		frontendServerSnapshot := filepath.Join(engineCachePath, "gen", "frontend_server.dart.snapshot")
-		flutterPatchedSdk := filepath.Join(engineCachePath, "flutter_patched_sdk")
-		generateKernelSnapshotCommand := []string{
-			darwinhacks.RewriteDarlingPath(useDarling, dart),
-			darwinhacks.RewriteDarlingPath(useDarling, frontendServerSnapshot),
-			"--sdk-root=" + darwinhacks.RewriteDarlingPath(useDarling, flutterPatchedSdk),
-			"--target=flutter",
-			"--aot",
-			"--tfa",
-			"-Ddart.vm.product=true",
-			"--packages=.packages",
-			"--output-dill=" + darwinhacks.RewriteDarlingPath(useDarling, kernelSnapshot),
-			buildOrRunFlutterTarget,
-		}
-		if useWine {
-			generateKernelSnapshotCommand = append([]string{"wine"}, generateKernelSnapshotCommand...)
-		}
-		if useDarling {
-			generateKernelSnapshotCommand = append([]string{"darling", "shell"}, generateKernelSnapshotCommand...)
-		}
-		cmdGenerateKernelSnapshot := exec.Command(
-			generateKernelSnapshotCommand[0],
-			generateKernelSnapshotCommand[1:]...,
-		)
-		cmdGenerateKernelSnapshot.Stdout = os.Stdout
-		cmdGenerateKernelSnapshot.Stderr = os.Stderr
-		log.Infof("Generating kernel snapshot")
-		err = cmdGenerateKernelSnapshot.Run()
-		if useDarling {
-			// Change back paths even if the command failed
-			darwinhacks.ChangePackagesFilePath(false)
-		}
-		if err != nil {
-			log.Errorf("Generating kernel snapshot failed: %v", err)
-			os.Exit(1)
-		}
-		generateAotSnapshotCommand := []string{
-			darwinhacks.RewriteDarlingPath(useDarling, genSnapshot),
-			"--lazy-async-stacks",
-			"--deterministic",
-			"--snapshot_kind=app-aot-elf",
-			"--elf=" + darwinhacks.RewriteDarlingPath(useDarling, elfSnapshot),
-		}
-		if useWine {
-			generateAotSnapshotCommand = append([]string{"wine"}, generateAotSnapshotCommand...)
-		}
-		if useDarling {
-			generateAotSnapshotCommand = append([]string{"darling", "shell"}, generateAotSnapshotCommand...)
-		}
-		if buildOrRunMode == build.ReleaseMode {
-			generateAotSnapshotCommand = append(generateAotSnapshotCommand, "--strip")
-		}
-		generateAotSnapshotCommand = append(generateAotSnapshotCommand, darwinhacks.RewriteDarlingPath(useDarling, kernelSnapshot))
-		cmdGenerateAotSnapshot := exec.Command(
-			generateAotSnapshotCommand[0],
-			generateAotSnapshotCommand[1:]...,
-		)
-		cmdGenerateAotSnapshot.Stdout = os.Stdout
-		cmdGenerateAotSnapshot.Stderr = os.Stderr
-		log.Infof("Generating ELF snapshot")
-		err = cmdGenerateAotSnapshot.Run()
-		if err != nil {
-			log.Errorf("Generating AOT snapshot failed: %v", err)
-			os.Exit(1)
-		}
-		err = os.Remove(kernelSnapshot)
-		if err != nil {
-			log.Errorf("Failed to remove kernel_snapshot.dill: %v", err)
-			os.Exit(1)
-		}
-	}
 }
 
-func buildGoBinary(targetOS string, vmArguments []string) {
+// buildApp builds the flutter bundle and the go binary of opts.TargetOS.
+// When opts.FlutterBundleOS is not empty, the flutter assets are copied from
+// its output instead of running `flutter build bundle` again.
+func buildApp(ctx context.Context, opts hover.BuildOptions) error {
 	if vmArgsFromEnv := os.Getenv("HOVER_IN_DOCKER_BUILD_VMARGS"); len(vmArgsFromEnv) > 0 {
-		vmArguments = append(vmArguments, strings.Split(vmArgsFromEnv, ",")...)
+		opts.VMArguments = append(append([]string(nil), opts.VMArguments...), strings.Split(vmArgsFromEnv, ",")...)
 	}
-
-	fileutils.CopyDir(build.IntermediatesDirectoryPath(targetOS, buildOrRunMode), build.OutputDirectoryPath(targetOS, buildOrRunMode))
-
-	for _, engineFile := range build.EngineFiles(targetOS, buildOrRunMode) {
-		outputEngineFile := filepath.Join(build.OutputDirectoryPath(targetOS, buildOrRunMode), engineFile)
-		if _, err := os.Stat(outputEngineFile); err == nil || os.IsExist(err) {
-			err = os.RemoveAll(outputEngineFile)
-			if err != nil {
-				log.Errorf("Failed to remove old engine: %v", err)
-				os.Exit(1)
-			}
-		}
-		err := copy.Copy(
-			filepath.Join(engineCachePath, engineFile),
-			outputEngineFile,
-		)
-		if err != nil {
-			log.Errorf("Failed to copy %s: %v", engineFile, err)
-			os.Exit(1)
-		}
+	if !buildOrRunSkipFlutter && opts.FlutterBundleOS == "" {
+		prepareFlutterBuild(ctx)
+	}
+	if !buildOrRunSkipEmbedder {
+		prepareGoBuild(ctx, opts.TargetOS)
 	}
+	return hover.Build(ctx, opts)
+}
 
-	err := copy.Copy(
-		filepath.Join(engineCachePath, "icudtl.dat"),
-		filepath.Join(build.OutputDirectoryPath(targetOS, buildOrRunMode), "icudtl.dat"),
-	)
+// prepareFlutterBuild runs the checks of the flutter bundle that may need
+// input from the user.
+func prepareFlutterBuild(ctx context.Context) {
+	assertTargetFileExists(buildOrRunFlutterTarget)
+
+	runPluginGet, err := shouldRunPluginGet()
 	if err != nil {
-		log.Errorf("Failed to copy icudtl.dat: %v", err)
+		log.Errorf("Failed to check if plugin get should be run: %v.\n", err)
 		os.Exit(1)
 	}
+	if runPluginGet {
+		log.Printf("listing available plugins:")
+		if hoverPluginGet(ctx, true) {
+			// TODO: change this so that it only logs when there are plugins missing..
+			log.Infof("Run `%s` to update plugins", log.Au().Magenta("hover plugins get"))
+		}
+	}
 
-	fileutils.CopyDir(
-		filepath.Join(build.BuildPath, "assets"),
-		filepath.Join(build.OutputDirectoryPath(targetOS, buildOrRunMode), "assets"),
-	)
+	checkFlutterChannel(ctx)
+}
 
+// prepareGoBuild upgrades 'go-flutter' when it is required, before the go
+// binary is built.
+func prepareGoBuild(ctx context.Context, targetOS string) {
 	wd, err := os.Getwd()
 	if err != nil {
 		log.Errorf("Failed to get working dir: %v", err)
@@ -634,7 +821,7 @@ func buildGoBinary(targetOS string, vmArguments []string) {
 			requiredGoFlutterVersion, err := version.NewSemver("v0.42.0")
 			if !semver.GreaterThanOrEqual(requiredGoFlutterVersion) {
 				log.Warnf("Hover requires at least go-flutter v0.42.0. Upgrading now")
-				err = upgradeGoFlutter(targetOS)
+				err = upgradeGoFlutter(ctx, targetOS)
 				if err != nil {
 					log.Errorf("Upgrade failed. Please run `hover bumpversion` manually")
 					os.Exit(1)
@@ -645,7 +832,7 @@ func buildGoBinary(targetOS string, vmArguments []string) {
 				log.Infof("Upgrading 'go-flutter' to the latest release")
 				// no buildBranch provided and currentTag isn't a release,
 				// force update. (same behaviour as previous version of hover).
-				err = upgradeGoFlutter(targetOS)
+				err = upgradeGoFlutter(ctx, targetOS)
 				if err != nil {
 					// the upgrade can fail silently
 					log.Warnf("Upgrade ignored, current 'go-flutter' version: %s", currentTag)
@@ -661,141 +848,11 @@ func buildGoBinary(targetOS string, vmArguments []string) {
 		log.Printf("Downloading 'go-flutter' %s", buildOrRunGoFlutterBranch)
 
 		// when the buildBranch is set, fetch the go-flutter branch version.
-		err = upgradeGoFlutter(targetOS)
+		err = upgradeGoFlutter(ctx, targetOS)
 		if err != nil {
 			os.Exit(1)
 		}
 	}
 
 	versioncheck.CheckForHoverUpdate(internalVersion.HoverVersion())
-
-	if buildOrRunOpenGlVersion == "none" {
-		log.Warnf("The '--opengl=none' flag makes go-flutter incompatible with texture plugins!")
-	}
-
-	buildCommandString := buildCommand(targetOS, vmArguments, build.OutputBinaryPath(config.GetConfig().GetExecutableName(pubspec.GetPubSpec().Name), targetOS, buildOrRunMode))
-	cmdGoBuild := exec.Command(buildCommandString[0], buildCommandString[1:]...)
-	cmdGoBuild.Dir = filepath.Join(wd, build.BuildPath)
-	cmdGoBuild.Env = append(os.Environ(),
-		buildEnv(targetOS, engineCachePath)...,
-	)
-
-	cmdGoBuild.Stderr = os.Stderr
-	cmdGoBuild.Stdout = os.Stdout
-
-	log.Infof("Compiling 'go-flutter' and plugins")
-	err = cmdGoBuild.Run()
-	if err != nil {
-		log.Errorf("Go build failed: %v", err)
-		os.Exit(1)
-	}
-	log.Infof("Successfully compiled executable binary for %s", targetOS)
-	if targetOS == "darwin" && buildOrRunMode != build.DebugMode {
-		darwinhacks.DyldHack(build.OutputBinaryPath(config.GetConfig().GetExecutableName(pubspec.GetPubSpec().Name), targetOS, buildOrRunMode))
-	}
-}
-
-func buildEnv(targetOS string, engineCachePath string) []string {
-	var cgoLdflags = os.Getenv("CGO_LDFLAGS")
-	var cgoCflags = os.Getenv("CGO_CFLAGS")
-
-	outputDirPath := build.OutputDirectoryPath(targetOS, buildOrRunMode)
-
-	switch targetOS {
-	case "darwin":
-		if buildOrRunMode == build.DebugMode {
-			cgoLdflags += fmt.Sprintf(" -F%s -Wl,-rpath,@executable_path", engineCachePath)
-			cgoLdflags += fmt.Sprintf(" -F%s -L%s -framework FlutterEmbedder", outputDirPath, outputDirPath)
-		} else {
-			cgoLdflags += fmt.Sprintf(" -L%s -L%s", engineCachePath, outputDirPath)
-			cgoLdflags += " -lflutter_engine -Wl,-rpath,."
-		}
-		if runtime.GOOS == targetOS {
-			cgoLdflags += " -mmacosx-version-min=10.11"
-			cgoCflags += " -mmacosx-version-min=10.11"
-		} else {
-			// OSX cross available in golang-cross (Docker) only supports up to 10.10
-			cgoLdflags += " -mmacosx-version-min=10.10"
-			cgoCflags += " -mmacosx-version-min=10.10"
-		}
-	case "linux":
-		cgoLdflags += fmt.Sprintf(" -L%s -L%s", engineCachePath, outputDirPath)
-		cgoLdflags += fmt.Sprintf(" -lflutter_engine -Wl,-rpath,$ORIGIN")
-	case "windows":
-		cgoLdflags += fmt.Sprintf(" -L%s -L%s", engineCachePath, outputDirPath)
-		cgoLdflags += fmt.Sprintf(" -lflutter_engine")
-	default:
-		log.Errorf("Target platform %s is not supported, cgo_ldflags not implemented.", targetOS)
-		os.Exit(1)
-	}
-	env := []string{
-		"GO111MODULE=on",
-		"CGO_LDFLAGS=" + cgoLdflags,
-		"CGO_CFLAGS=" + cgoCflags,
-		"GOOS=" + targetOS,
-		"GOARCH=amd64",
-		"CGO_ENABLED=1",
-	}
-	if runtime.GOOS == "linux" {
-		if targetOS == "windows" {
-			env = append(env,
-				"CC="+mingwGccBinName,
-			)
-		}
-		if targetOS == "darwin" {
-			env = append(env,
-				"CC="+clangBinName,
-			)
-		}
-	}
-	return env
-}
-
-func buildCommand(targetOS string, vmArguments []string, outputBinaryPath string) []string {
-	absPath, err := filepath.Abs(build.BuildPath)
-	if err != nil {
-		log.Errorf("unable to detect absolute path: %s - %v", build.BuildPath, err)
-		os.Exit(1)
-	}
-
-	currentTag, err := versioncheck.CurrentGoFlutterTag(absPath)
-	if err != nil {
-		log.Errorf("%v", err)
-		os.Exit(1)
-	}
-
-	var ldflags []string
-	if buildOrRunMode != build.DebugMode {
-		vmArguments = append(vmArguments, "--disable-dart-asserts")
-		vmArguments = append(vmArguments, "--disable-observatory")
-
-		if targetOS == "windows" {
-			ldflags = append(ldflags, "-H=windowsgui")
-		}
-		ldflags = append(ldflags, "-s")
-		ldflags = append(ldflags, "-w")
-	}
-	ldflags = append(ldflags, fmt.Sprintf("-X main.vmArguments=%s", strings.Join(vmArguments, ";")))
-	// overwrite go-flutter build-constants values
-	ldflags = append(ldflags, fmt.Sprintf(
-		"-X 'github.com/go-flutter-desktop/go-flutter.ProjectVersion=%s' "+
-			" -X 'github.com/go-flutter-desktop/go-flutter.PlatformVersion=%s' "+
-			" -X 'github.com/go-flutter-desktop/go-flutter.ProjectName=%s' "+
-			" -X 'github.com/go-flutter-desktop/go-flutter.ProjectOrganizationName=%s'",
-		buildVersionNumber,
-		currentTag,
-		config.GetConfig().GetApplicationName(pubspec.GetPubSpec().Name),
-		config.GetConfig().GetOrganizationName()))
-
-	outputCommand := []string{
-		"go",
-		"build",
-		"-tags=opengl" + buildOrRunOpenGlVersion,
-		"-tags=no_engine_tags",
-		"-o", outputBinaryPath,
-		"-v",
-	}
-	outputCommand = append(outputCommand, fmt.Sprintf("-ldflags=%s", strings.Join(ldflags, " ")))
-	outputCommand = append(outputCommand, dotSlash+"cmd")
-	return outputCommand
 }
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -28,19 +29,23 @@ var upgradeCmd = &cobra.Command{
 		// Hardcode target to the current OS (no cross-compile for this command)
 		targetOS := runtime.GOOS
 
-		err := upgrade(targetOS)
+		err := upgrade(cmd.Context(), targetOS)
 		if err != nil {
 			os.Exit(1)
 		}
 	},
 }
 
-func upgrade(targetOS string) (err error) {
-	enginecache.ValidateOrUpdateEngine(targetOS, buildOrRunCachePath, "", build.DebugMode)
-	return upgradeGoFlutter(targetOS)
+func upgrade(ctx context.Context, targetOS string) (err error) {
+	err = enginecache.ValidateOrUpdateEngine(ctx, targetOS, build.DefaultArch, buildOrRunCachePath, "", engineMirror(), build.DebugMode)
+	if err != nil {
+		log.Errorf("%v", err)
+		return err
+	}
+	return upgradeGoFlutter(ctx, targetOS)
 }
 
-func upgradeGoFlutter(targetOS string) (err error) {
+func upgradeGoFlutter(ctx context.Context, targetOS string) (err error) {
 	wd, err := os.Getwd()
 	if err != nil {
 		log.Errorf("Failed to get working dir: %v", err)
@@ -51,7 +56,7 @@ func upgradeGoFlutter(targetOS string) (err error) {
 		buildOrRunGoFlutterBranch = "@latest"
 	}
 
-	cmdGoGetU := exec.Command(build.GoBin(), "get", "-u", "-d", "github.com/go-flutter-desktop/go-flutter"+buildOrRunGoFlutterBranch)
+	cmdGoGetU := exec.CommandContext(ctx, goBin(), "get", "-u", "-d", "github.com/go-flutter-desktop/go-flutter"+buildOrRunGoFlutterBranch)
 	cmdGoGetU.Env = append(os.Environ(),
 		"GO111MODULE=on",
 	)
@@ -66,7 +71,7 @@ func upgradeGoFlutter(targetOS string) (err error) {
 		return
 	}
 
-	cmdGoModDownload := exec.Command(build.GoBin(), "mod", "download")
+	cmdGoModDownload := exec.CommandContext(ctx, goBin(), "mod", "download")
 	cmdGoModDownload.Dir = filepath.Join(wd, build.BuildPath)
 	cmdGoModDownload.Env = append(os.Environ(),
 		"GO111MODULE=on",
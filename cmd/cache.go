@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/go-flutter-desktop/hover/internal/build"
+	"github.com/go-flutter-desktop/hover/internal/enginecache"
+	"github.com/go-flutter-desktop/hover/internal/log"
+)
+
+var (
+	pruneKeepVersions int
+	pruneOlderThan    string
+	pruneTargetOS     string
+	pruneDryRun       bool
+)
+
+func init() {
+	cacheCmd.PersistentFlags().StringVar(&cachePath, "cache-path", enginecache.DefaultCachePath(), "The path that hover uses to cache dependencies such as the Flutter engine .so/.dll")
+	cachePruneCmd.Flags().IntVar(&pruneKeepVersions, "keep-versions", 0, "Remove the engines that aren't one of the N most recently used engine versions.")
+	cachePruneCmd.Flags().StringVar(&pruneOlderThan, "older-than", "", "Remove the engines that haven't been used for this long, e.g. 30d or 12h.")
+	cachePruneCmd.Flags().StringVar(&pruneTargetOS, "target", "", "Only remove engines of this OS: linux, darwin or windows.")
+	cachePruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "Print the engines that would be removed without removing them.")
+	cacheCmd.AddCommand(cacheListCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and clean up the cached engine files",
+}
+
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the cached engines",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			return errors.New("does not take arguments")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, err := enginecache.ListCache(cachePath)
+		if err != nil {
+			log.Errorf("%v", err)
+			os.Exit(1)
+		}
+		if len(entries) == 0 {
+			log.Infof("The engine cache at %s is empty", enginecache.BaseEngineCachePath(cachePath))
+			return
+		}
+		printCacheEntries(entries)
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove the cached engines selected by --keep-versions, --older-than and --target",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			return errors.New("does not take arguments")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		policy := enginecache.PrunePolicy{
+			KeepVersions: pruneKeepVersions,
+			TargetOS:     pruneTargetOS,
+		}
+		if pruneOlderThan != "" {
+			var err error
+			policy.OlderThan, err = parseAge(pruneOlderThan)
+			if err != nil {
+				log.Errorf("Invalid --older-than: %v", err)
+				os.Exit(1)
+			}
+		}
+		if policy.TargetOS != "" {
+			if err := build.ValidateTargetOS(policy.TargetOS); err != nil {
+				log.Errorf("%v", err)
+				os.Exit(1)
+			}
+		}
+		if policy == (enginecache.PrunePolicy{}) {
+			log.Errorf("At least one of --keep-versions, --older-than or --target must be set. Use `hover clean-cache` to remove all engines.")
+			os.Exit(1)
+		}
+
+		pruned, err := enginecache.PruneCache(cmd.Context(), cachePath, policy, pruneDryRun)
+		if err != nil {
+			log.Errorf("%v", err)
+			os.Exit(1)
+		}
+		if len(pruned) == 0 {
+			log.Infof("No engines to remove")
+			return
+		}
+		var size int64
+		for _, entry := range pruned {
+			size += entry.Size
+		}
+		if pruneDryRun {
+			log.Infof("Would remove %d engines, %s:", len(pruned), formatSize(size))
+		} else {
+			log.Infof("Removed %d engines, %s:", len(pruned), formatSize(size))
+		}
+		printCacheEntries(pruned)
+	},
+}
+
+func printCacheEntries(entries []enginecache.CacheEntry) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ENGINE\tENGINE VERSION\tHOVER VERSION\tSIZE\tLAST USED")
+	for _, entry := range entries {
+		engineVersion := entry.EngineVersion
+		if engineVersion == "" {
+			engineVersion = "(incomplete)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", entry.Name, engineVersion, entry.HoverVersion, formatSize(entry.Size), entry.LastUsed.Format("2006-01-02 15:04"))
+	}
+	w.Flush()
+}
+
+func formatSize(size int64) string {
+	return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+}
+
+// parseAge parses a duration which, in addition to the units of
+// time.ParseDuration, may be given in days, e.g. 30d.
+func parseAge(age string) (time.Duration, error) {
+	if strings.HasSuffix(age, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(age, "d"))
+		if err != nil || days <= 0 {
+			return 0, errors.Errorf("%q is not a number of days", age)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	duration, err := time.ParseDuration(age)
+	if err != nil {
+		return 0, err
+	}
+	if duration <= 0 {
+		return 0, errors.Errorf("%q is not a positive duration", age)
+	}
+	return duration, nil
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -14,6 +15,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/go-flutter-desktop/hover/internal/build"
+	"github.com/go-flutter-desktop/hover/internal/config"
 	"github.com/go-flutter-desktop/hover/internal/log"
 	"github.com/go-flutter-desktop/hover/internal/pubspec"
 	"github.com/go-flutter-desktop/hover/internal/version"
@@ -45,10 +47,49 @@ func assertHoverInitialized() {
 		log.Errorf("Failed to detect directory desktop: %v", err)
 		os.Exit(1)
 	}
+	assertHoverConfigLoaded()
 }
 
-func checkFlutterChannel() {
-	channel := version.FlutterChannel()
+// assertHoverConfigLoaded loads hover.yaml and exits when it is invalid.
+func assertHoverConfigLoaded() {
+	_, err := config.LoadConfig()
+	if err != nil {
+		log.Errorf("%v", err)
+		os.Exit(1)
+	}
+}
+
+func goBin() string {
+	return assertBin(build.GoBin())
+}
+
+func flutterBin() string {
+	return assertBin(build.FlutterBin())
+}
+
+func gitBin() string {
+	return assertBin(build.GitBin())
+}
+
+func dockerBin() string {
+	return assertBin(build.DockerBin())
+}
+
+// assertBin exits when the lookup of an executable failed.
+func assertBin(path string, err error) string {
+	if err != nil {
+		log.Errorf("%v", err)
+		os.Exit(1)
+	}
+	return path
+}
+
+func checkFlutterChannel(ctx context.Context) {
+	channel, err := version.FlutterChannel(ctx)
+	if err != nil {
+		log.Errorf("%v", err)
+		os.Exit(1)
+	}
 	ignoreWarning := os.Getenv("HOVER_IGNORE_CHANNEL_WARNING")
 	if channel != "beta" && ignoreWarning != "true" {
 		log.Warnf("⚠ The go-flutter project tries to stay compatible with the beta channel of Flutter.")
@@ -116,14 +157,14 @@ func toCamelCase(str string) string {
 }
 
 // initializeGoModule uses the golang binary to initialize the go module
-func initializeGoModule(projectPath string) {
+func initializeGoModule(ctx context.Context, projectPath string) {
 	wd, err := os.Getwd()
 	if err != nil {
 		log.Errorf("Failed to get working dir: %v\n", err)
 		os.Exit(1)
 	}
 
-	cmdGoModInit := exec.Command(build.GoBin(), "mod", "init", projectPath+"/"+build.BuildPath)
+	cmdGoModInit := exec.CommandContext(ctx, goBin(), "mod", "init", projectPath+"/"+build.BuildPath)
 	cmdGoModInit.Dir = filepath.Join(wd, build.BuildPath)
 	cmdGoModInit.Env = append(os.Environ(),
 		"GO111MODULE=on",
@@ -136,7 +177,7 @@ func initializeGoModule(projectPath string) {
 		os.Exit(1)
 	}
 
-	cmdGoModTidy := exec.Command(build.GoBin(), "mod", "tidy")
+	cmdGoModTidy := exec.CommandContext(ctx, goBin(), "mod", "tidy")
 	cmdGoModTidy.Dir = filepath.Join(wd, build.BuildPath)
 	log.Infof("You can add the '%s' directory to git.", cmdGoModTidy.Dir)
 	cmdGoModTidy.Env = append(os.Environ(),
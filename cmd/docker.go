@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -9,16 +10,14 @@ import (
 	"runtime"
 	"strings"
 
-	"github.com/go-flutter-desktop/hover/cmd/packaging"
-	"github.com/go-flutter-desktop/hover/internal/build"
 	"github.com/go-flutter-desktop/hover/internal/log"
 	"github.com/go-flutter-desktop/hover/internal/logstreamer"
 	"github.com/go-flutter-desktop/hover/internal/version"
 )
 
-func dockerHoverBuild(targetOS string, packagingTask packaging.Task, buildFlags []string, vmArguments []string) {
+func dockerHoverBuild(ctx context.Context, targetNames []string, buildFlags []string, vmArguments []string) {
 	var err error
-	dockerBin := build.DockerBin()
+	dockerBin := dockerBin()
 
 	hoverCacheDir := filepath.Join(buildOrRunCachePath, "hover")
 
@@ -60,20 +59,23 @@ func dockerHoverBuild(targetOS string, packagingTask packaging.Task, buildFlags
 		dockerArgs = append(dockerArgs, "--env", "HOVER_SAFE_CHOWN_UID="+currentUser.Uid)
 		dockerArgs = append(dockerArgs, "--env", "HOVER_SAFE_CHOWN_GID="+currentUser.Gid)
 	}
-	goproxy, err := exec.Command("go", "env", "GOPROXY").Output()
+	goproxy, err := exec.CommandContext(ctx, "go", "env", "GOPROXY").Output()
 	if err != nil {
 		log.Errorf("Failed to get GOPROXY: %v", err)
 	}
 	if string(goproxy) != "" {
 		dockerArgs = append(dockerArgs, "--env", "GOPROXY="+string(goproxy))
 	}
-	goprivate, err := exec.Command("go", "env", "GOPRIVATE").Output()
+	goprivate, err := exec.CommandContext(ctx, "go", "env", "GOPRIVATE").Output()
 	if err != nil {
 		log.Errorf("Failed to get GOPRIVATE: %v", err)
 	}
 	if string(goprivate) != "" {
 		dockerArgs = append(dockerArgs, "--env", "GOPRIVATE="+string(goprivate))
 	}
+	if sourceDateEpoch := os.Getenv("SOURCE_DATE_EPOCH"); sourceDateEpoch != "" {
+		dockerArgs = append(dockerArgs, "--env", "SOURCE_DATE_EPOCH="+sourceDateEpoch)
+	}
 	if len(vmArguments) > 0 {
 		// I (GeertJohan) am not too happy with this, it make the hover inside
 		// the container aware of it being inside the container. But for now
@@ -90,15 +92,11 @@ func dockerHoverBuild(targetOS string, packagingTask packaging.Task, buildFlags
 	}
 	dockerImage := "goflutter/hover:" + hoverVersion
 	dockerArgs = append(dockerArgs, dockerImage)
-	targetOSAndPackaging := targetOS
-	if packName := packagingTask.Name(); packName != "" {
-		targetOSAndPackaging = packName
-	}
-	hoverCommand := []string{"hover-safe.sh", "build", targetOSAndPackaging}
+	hoverCommand := append([]string{"hover-safe.sh", "build"}, targetNames...)
 	hoverCommand = append(hoverCommand, buildFlags...)
 	dockerArgs = append(dockerArgs, hoverCommand...)
 
-	dockerRunCmd := exec.Command(dockerBin, dockerArgs...)
+	dockerRunCmd := exec.CommandContext(ctx, dockerBin, dockerArgs...)
 	// TODO: remove debug line
 	fmt.Printf("Running this docker command: %v\n", dockerRunCmd.String())
 	dockerRunCmd.Stderr = logstreamer.NewLogstreamerForStderr("docker container: ")
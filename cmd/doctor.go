@@ -13,10 +13,10 @@ import (
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
 
-	"github.com/go-flutter-desktop/hover/cmd/packaging"
 	"github.com/go-flutter-desktop/hover/internal/build"
 	"github.com/go-flutter-desktop/hover/internal/config"
 	"github.com/go-flutter-desktop/hover/internal/log"
+	"github.com/go-flutter-desktop/hover/internal/packaging"
 	"github.com/go-flutter-desktop/hover/internal/version"
 )
 
@@ -58,7 +58,7 @@ var doctorCmd = &cobra.Command{
 		log.Printf("")
 
 		log.Infof("Sharing flutter version")
-		cmdFlutterVersion := exec.Command(build.FlutterBin(), "--version")
+		cmdFlutterVersion := exec.CommandContext(cmd.Context(), flutterBin(), "--version")
 		cmdFlutterVersion.Stderr = os.Stderr
 		cmdFlutterVersion.Stdout = os.Stdout
 		err := cmdFlutterVersion.Run()
@@ -66,12 +66,16 @@ var doctorCmd = &cobra.Command{
 			log.Errorf("Flutter --version failed: %v", err)
 		}
 
-		engineCommitHash := version.FlutterRequiredEngineVersion()
+		engineCommitHash, err := version.FlutterRequiredEngineVersion(cmd.Context())
+		if err != nil {
+			log.Errorf("%v", err)
+			os.Exit(1)
+		}
 		log.Infof("Flutter engine commit: %s", log.Au().Magenta("https://github.com/flutter/engine/commit/"+engineCommitHash))
 
-		checkFlutterChannel()
+		checkFlutterChannel(cmd.Context())
 
-		cmdGoEnvCC := exec.Command(build.GoBin(), "env", "CC")
+		cmdGoEnvCC := exec.CommandContext(cmd.Context(), goBin(), "env", "CC")
 		cmdGoEnvCCOut, err := cmdGoEnvCC.Output()
 		if err != nil {
 			log.Errorf("Go env CC failed: %v", err)
@@ -80,7 +84,7 @@ var doctorCmd = &cobra.Command{
 		cCompiler = strings.Trim(cCompiler, "\n")
 		if cCompiler != "" {
 			log.Infof("Finding out the C compiler version")
-			cmdCCVersion := exec.Command(cCompiler, "--version")
+			cmdCCVersion := exec.CommandContext(cmd.Context(), cCompiler, "--version")
 			cmdCCVersion.Stderr = os.Stderr
 			cmdCCVersion.Stdout = os.Stdout
 			cmdCCVersion.Run()
@@ -112,7 +116,7 @@ var doctorCmd = &cobra.Command{
 		log.Infof("Sharing the content of go/cmd")
 		files, err := filepath.Glob(filepath.Join(build.BuildPath, "cmd", "*"))
 		if err != nil {
-			log.Errorf("Failed to get the list of files in go/cmd", err)
+			log.Errorf("Failed to get the list of files in go/cmd: %v", err)
 			os.Exit(1)
 		}
 		fmt.Println(strings.Join(files, "\t"))
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/go-flutter-desktop/hover/internal/build"
+	"github.com/go-flutter-desktop/hover/internal/config"
+	"github.com/go-flutter-desktop/hover/internal/enginecache"
+	"github.com/go-flutter-desktop/hover/internal/log"
+)
+
+var (
+	engineCachePathFlag string
+	engineTargetOS      string
+	engineArch          string
+	engineModeName      string
+	engineVersionFlag   string
+	engineOutputPath    string
+)
+
+func init() {
+	engineCmd.PersistentFlags().StringVar(&engineCachePathFlag, "cache-path", enginecache.DefaultCachePath(), "The path that hover uses to cache dependencies such as the Flutter engine .so/.dll")
+	engineExportCmd.Flags().StringVar(&engineTargetOS, "target", runtime.GOOS, "The OS of the engine to export: linux, darwin or windows.")
+	engineExportCmd.Flags().StringVar(&engineArch, "arch", build.DefaultArch, fmt.Sprintf("The architecture of the engine to export. One of %v", build.SupportedArchs))
+	engineExportCmd.Flags().StringVar(&engineModeName, "mode", "debug", "The mode of the engine to export: debug, release or profile.")
+	engineExportCmd.Flags().StringVar(&engineVersionFlag, "engine-version", config.BuildEngineDefault, "The flutter engine version to export, defaults to the version required by the installed flutter SDK.")
+	engineExportCmd.Flags().StringVarP(&engineOutputPath, "output", "o", "", "The path of the archive to write. (default \"engine-<target>.tar.gz\")")
+	engineCmd.AddCommand(engineExportCmd)
+	engineCmd.AddCommand(engineImportCmd)
+	rootCmd.AddCommand(engineCmd)
+}
+
+var engineCmd = &cobra.Command{
+	Use:   "engine",
+	Short: "Export and import cached flutter engines, to seed machines without internet access",
+}
+
+var engineExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Pack a cached flutter engine into a portable archive",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 0 {
+			return errors.New("does not take arguments")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		mode, err := build.ParseMode(engineModeName)
+		if err != nil {
+			log.Errorf("%v", err)
+			os.Exit(1)
+		}
+		if err := build.ValidateTargetOS(engineTargetOS); err != nil {
+			log.Errorf("%v", err)
+			os.Exit(1)
+		}
+		if err := build.ValidateArch(engineArch); err != nil {
+			log.Errorf("%v", err)
+			os.Exit(1)
+		}
+		engineVersion, err := enginecache.ResolveEngineVersion(cmd.Context(), engineVersionFlag)
+		if err != nil {
+			log.Errorf("%v", err)
+			os.Exit(1)
+		}
+		if engineOutputPath == "" {
+			engineOutputPath = fmt.Sprintf("engine-%s.tar.gz", enginecache.EngineConfig(engineTargetOS, engineArch, mode))
+		}
+
+		file, err := os.Create(engineOutputPath)
+		if err != nil {
+			log.Errorf("Failed to create %s: %v", engineOutputPath, err)
+			os.Exit(1)
+		}
+		err = enginecache.ExportEngine(cmd.Context(), file, engineTargetOS, engineArch, engineCachePathFlag, engineVersion, mode)
+		if err == nil {
+			err = file.Close()
+		} else {
+			file.Close()
+		}
+		if err != nil {
+			os.Remove(engineOutputPath)
+			log.Errorf("Failed to export the engine: %v", err)
+			os.Exit(1)
+		}
+		log.Infof("Exported the %s engine to %s", enginecache.EngineConfig(engineTargetOS, engineArch, mode), engineOutputPath)
+	},
+}
+
+var engineImportCmd = &cobra.Command{
+	Use:   "import <archive>",
+	Short: "Install a flutter engine archive made by `hover engine export` in the cache",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("requires one argument, the path of the engine archive")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		file, err := os.Open(args[0])
+		if err != nil {
+			log.Errorf("Failed to open %s: %v", args[0], err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		manifest, err := enginecache.ImportEngine(cmd.Context(), file, engineCachePathFlag)
+		if err != nil {
+			log.Errorf("Failed to import %s: %v", args[0], err)
+			os.Exit(1)
+		}
+		log.Infof("Imported the %s %s %s engine at version %s", manifest.OS, manifest.Arch, manifest.Mode, manifest.Version)
+	},
+}
@@ -48,9 +48,17 @@ var createPluginCmd = &cobra.Command{
 			"urlVSCRepo": vcsPath,
 		}
 
-		fileutils.ExecuteTemplateFromAssets("plugin/plugin.go.tmpl", filepath.Join(build.BuildPath, "plugin.go"), templateData)
-		fileutils.ExecuteTemplateFromAssets("plugin/README.md.tmpl", filepath.Join(build.BuildPath, "README.md"), templateData)
-		fileutils.ExecuteTemplateFromAssets("plugin/import.go.tmpl.tmpl", filepath.Join(build.BuildPath, "import.go.tmpl"), templateData)
+		for asset, destination := range map[string]string{
+			"plugin/plugin.go.tmpl":      filepath.Join(build.BuildPath, "plugin.go"),
+			"plugin/README.md.tmpl":      filepath.Join(build.BuildPath, "README.md"),
+			"plugin/import.go.tmpl.tmpl": filepath.Join(build.BuildPath, "import.go.tmpl"),
+		} {
+			err = fileutils.ExecuteTemplateFromAssets(asset, destination, templateData)
+			if err != nil {
+				log.Errorf("%v", err)
+				os.Exit(1)
+			}
+		}
 
 		dlibPath := filepath.Join(build.BuildPath, "dlib")
 		err = os.Mkdir(dlibPath, 0775)
@@ -58,7 +66,11 @@ var createPluginCmd = &cobra.Command{
 			log.Errorf("Failed to create '%s' directory: %v", dlibPath, err)
 			os.Exit(1)
 		}
-		fileutils.ExecuteTemplateFromAssets("plugin/README.md.dlib.tmpl", filepath.Join(dlibPath, "README.md"), templateData)
+		err = fileutils.ExecuteTemplateFromAssets("plugin/README.md.dlib.tmpl", filepath.Join(dlibPath, "README.md"), templateData)
+		if err != nil {
+			log.Errorf("%v", err)
+			os.Exit(1)
+		}
 
 		platforms := []string{"darwin", "linux", "windows"}
 		for _, platform := range platforms {
@@ -69,6 +81,6 @@ var createPluginCmd = &cobra.Command{
 				os.Exit(1)
 			}
 		}
-		initializeGoModule(vcsPath)
+		initializeGoModule(cmd.Context(), vcsPath)
 	},
 }
@@ -65,17 +65,29 @@ var initCmd = &cobra.Command{
 
 		emptyConfig := config.Config{}
 
-		fileutils.CopyAsset("app/main.go.tmpl", filepath.Join(desktopCmdPath, "main.go"))
-		fileutils.CopyAsset("app/options.go.tmpl", filepath.Join(desktopCmdPath, "options.go"))
-		fileutils.CopyAsset("app/icon.png", filepath.Join(desktopAssetsPath, "icon.png"))
-		fileutils.CopyAsset("app/gitignore", filepath.Join(build.BuildPath, ".gitignore"))
-		fileutils.ExecuteTemplateFromAssets("app/hover.yaml.tmpl", filepath.Join(build.BuildPath, "hover.yaml"), map[string]string{
+		for asset, destination := range map[string]string{
+			"app/main.go.tmpl":    filepath.Join(desktopCmdPath, "main.go"),
+			"app/options.go.tmpl": filepath.Join(desktopCmdPath, "options.go"),
+			"app/icon.png":        filepath.Join(desktopAssetsPath, "icon.png"),
+			"app/gitignore":       filepath.Join(build.BuildPath, ".gitignore"),
+		} {
+			err = fileutils.CopyAsset(asset, destination)
+			if err != nil {
+				log.Errorf("%v", err)
+				os.Exit(1)
+			}
+		}
+		err = fileutils.ExecuteTemplateFromAssets("app/hover.yaml.tmpl", filepath.Join(build.BuildPath, "hover.yaml"), map[string]string{
 			"applicationName": emptyConfig.GetApplicationName(projectName),
 			"executableName":  emptyConfig.GetExecutableName(projectName),
 			"packageName":     emptyConfig.GetPackageName(projectName),
 		})
+		if err != nil {
+			log.Errorf("%v", err)
+			os.Exit(1)
+		}
 
-		initializeGoModule(projectPath)
+		initializeGoModule(cmd.Context(), projectPath)
 		log.Printf("Available plugin for this project:")
 		pluginListCmd.Run(cmd, []string{})
 	},
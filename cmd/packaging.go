@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"os"
+
 	"github.com/spf13/cobra"
 
-	"github.com/go-flutter-desktop/hover/cmd/packaging"
+	"github.com/go-flutter-desktop/hover/internal/log"
+	"github.com/go-flutter-desktop/hover/internal/packaging"
 )
 
 func init() {
@@ -30,7 +33,7 @@ var initLinuxSnapCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		assertHoverInitialized()
 
-		packaging.LinuxSnapTask.Init()
+		initPackagingTask(packaging.LinuxSnapTask)
 	},
 }
 
@@ -40,7 +43,7 @@ var initLinuxDebCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		assertHoverInitialized()
 
-		packaging.LinuxDebTask.Init()
+		initPackagingTask(packaging.LinuxDebTask)
 	},
 }
 
@@ -50,7 +53,7 @@ var initLinuxAppImageCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		assertHoverInitialized()
 
-		packaging.LinuxAppImageTask.Init()
+		initPackagingTask(packaging.LinuxAppImageTask)
 	},
 }
 var initLinuxRpmCmd = &cobra.Command{
@@ -59,7 +62,7 @@ var initLinuxRpmCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		assertHoverInitialized()
 
-		packaging.LinuxRpmTask.Init()
+		initPackagingTask(packaging.LinuxRpmTask)
 	},
 }
 var initLinuxPkgCmd = &cobra.Command{
@@ -68,7 +71,7 @@ var initLinuxPkgCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		assertHoverInitialized()
 
-		packaging.LinuxPkgTask.Init()
+		initPackagingTask(packaging.LinuxPkgTask)
 	},
 }
 var initWindowsMsiCmd = &cobra.Command{
@@ -77,7 +80,7 @@ var initWindowsMsiCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		assertHoverInitialized()
 
-		packaging.WindowsMsiTask.Init()
+		initPackagingTask(packaging.WindowsMsiTask)
 	},
 }
 
@@ -87,7 +90,7 @@ var initDarwinBundleCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		assertHoverInitialized()
 
-		packaging.DarwinBundleTask.Init()
+		initPackagingTask(packaging.DarwinBundleTask)
 	},
 }
 
@@ -97,7 +100,7 @@ var initDarwinPkgCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		assertHoverInitialized()
 
-		packaging.DarwinPkgTask.Init()
+		initPackagingTask(packaging.DarwinPkgTask)
 	},
 }
 
@@ -107,6 +110,14 @@ var initDarwinDmgCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		assertHoverInitialized()
 
-		packaging.DarwinDmgTask.Init()
+		initPackagingTask(packaging.DarwinDmgTask)
 	},
 }
+
+func initPackagingTask(task packaging.Task) {
+	err := task.Init()
+	if err != nil {
+		log.Errorf("%v", err)
+		os.Exit(1)
+	}
+}
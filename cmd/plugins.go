@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -155,7 +156,7 @@ var pluginListCmd = &cobra.Command{
 			}
 		}
 		if hasNewPlugin {
-			log.Infof(fmt.Sprintf("run `%s` to import the missing plugins!", log.Au().Magenta("hover plugins get")))
+			log.Infof("run `%s` to import the missing plugins!", log.Au().Magenta("hover plugins get"))
 		}
 	},
 }
@@ -179,7 +180,7 @@ var pluginTidyCmd = &cobra.Command{
 
 		gomod, err = modx.Open(build.BuildPath)
 		if err != nil {
-			log.Errorf("failed to open go.mod", err)
+			log.Errorf("failed to open go.mod: %v", err)
 			os.Exit(1)
 		}
 
@@ -283,11 +284,11 @@ var pluginGetCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		assertInFlutterProject()
 		assertHoverInitialized()
-		hoverPluginGet(false)
+		hoverPluginGet(cmd.Context(), false)
 	},
 }
 
-func hoverPluginGet(dryRun bool) bool {
+func hoverPluginGet(ctx context.Context, dryRun bool) bool {
 	dependencyList, err := listPlatformPlugin()
 	if err != nil {
 		log.Errorf("%v", err)
@@ -322,7 +323,7 @@ func hoverPluginGet(dryRun bool) bool {
 				continue
 			}
 
-			if !goGetModuleSuccess(pluginImportStr, dep.Version) {
+			if !goGetModuleSuccess(ctx, pluginImportStr, dep.Version) {
 				log.Warnf("Couldn't download version '%s' of plugin '%s'", dep.Version, dep.name)
 				log.Warnf("Fallback to the latest version installed.")
 				continue
@@ -333,10 +334,18 @@ func hoverPluginGet(dryRun bool) bool {
 		}
 
 		if dep.standaloneImpl {
-			fileutils.DownloadFile(dep.pluginGoSource, pluginImportOutPath)
+			err := fileutils.DownloadFile(dep.pluginGoSource, pluginImportOutPath)
+			if err != nil {
+				log.Errorf("%v", err)
+				os.Exit(1)
+			}
 		} else {
 			autoImportTemplatePath := filepath.Join(dep.pluginGoSource, "import.go.tmpl")
-			fileutils.CopyFile(autoImportTemplatePath, pluginImportOutPath)
+			err := fileutils.CopyFile(autoImportTemplatePath, pluginImportOutPath)
+			if err != nil {
+				log.Errorf("%v", err)
+				os.Exit(1)
+			}
 
 			if fileutils.IsDirectory(filepath.Join(dep.pluginGoSource, "dlib")) {
 				dlibPath, err := filepath.Abs(filepath.Join(dep.pluginGoSource, "dlib"))
@@ -351,10 +360,18 @@ func hoverPluginGet(dryRun bool) bool {
 					os.Exit(1)
 				}
 
-				fileutils.CopyDir(dlibPath, intermediatesDirectoryPath)
+				err = fileutils.CopyDir(dlibPath, intermediatesDirectoryPath)
+				if err != nil {
+					log.Errorf("%v", err)
+					os.Exit(1)
+				}
 				if fileutils.IsFileExists(filepath.Join(dlibPath, "README.md")) {
 					readmeName := fmt.Sprintf("README-%s.md", dep.name)
-					fileutils.CopyFile(filepath.Join(dlibPath, "README.md"), filepath.Join(intermediatesDirectoryPath, readmeName))
+					err = fileutils.CopyFile(filepath.Join(dlibPath, "README.md"), filepath.Join(intermediatesDirectoryPath, readmeName))
+					if err != nil {
+						log.Errorf("%v", err)
+						os.Exit(1)
+					}
 					_ = os.Remove(filepath.Join(intermediatesDirectoryPath, "README.md"))
 				}
 			}
@@ -368,7 +385,7 @@ func hoverPluginGet(dryRun bool) bool {
 
 			// if remote plugin, get the correct version
 			if dep.path == "" {
-				if !goGetModuleSuccess(pluginImportStr, dep.Version) {
+				if !goGetModuleSuccess(ctx, pluginImportStr, dep.Version) {
 					log.Warnf("Couldn't download version '%s' of plugin '%s'", dep.Version, dep.name)
 					log.Warnf("Fallback to the latest version available on github.")
 				}
@@ -595,8 +612,8 @@ func fetchStandaloneImplementationList() ([]StandaloneImplementation, error) {
 }
 
 // goGetModuleSuccess updates a module at a version, if it fails, return false.
-func goGetModuleSuccess(pluginImportStr, version string) bool {
-	cmdGoGetU := exec.Command(build.GoBin(), "get", "-u", "-d", pluginImportStr+"@v"+version)
+func goGetModuleSuccess(ctx context.Context, pluginImportStr, version string) bool {
+	cmdGoGetU := exec.CommandContext(ctx, goBin(), "get", "-u", "-d", pluginImportStr+"@v"+version)
 	cmdGoGetU.Dir = filepath.Join(build.BuildPath)
 	cmdGoGetU.Env = append(os.Environ(),
 		"GOPROXY=direct", // github.com/golang/go/issues/32955 (allows '/' in branch name)
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"runtime"
 
@@ -8,11 +10,13 @@ import (
 	"github.com/go-flutter-desktop/hover/internal/config"
 	"github.com/go-flutter-desktop/hover/internal/enginecache"
 	"github.com/go-flutter-desktop/hover/internal/log"
+	"github.com/go-flutter-desktop/hover/pkg/hover"
 	"github.com/spf13/cobra"
 )
 
 var (
 	prepareCachePath     string
+	prepareArch          string
 	prepareEngineVersion string
 	prepareReleaseMode   bool
 	prepareDebugMode     bool
@@ -22,6 +26,7 @@ var (
 
 func init() {
 	prepareEngineCmd.PersistentFlags().StringVar(&prepareCachePath, "cache-path", enginecache.DefaultCachePath(), "The path that hover uses to cache dependencies such as the Flutter engine .so/.dll")
+	prepareEngineCmd.PersistentFlags().StringVar(&prepareArch, "arch", build.DefaultArch, fmt.Sprintf("The architecture to prepare the flutter engine for. One of %v", build.SupportedArchs))
 	prepareEngineCmd.PersistentFlags().StringVar(&prepareEngineVersion, "engine-version", config.BuildEngineDefault, "The flutter engine version to use.")
 	prepareEngineCmd.PersistentFlags().BoolVar(&prepareDebugMode, "debug", false, "Prepare the flutter engine for debug mode")
 	prepareEngineCmd.PersistentFlags().BoolVar(&prepareReleaseMode, "release", false, "Prepare the flutter engine for release mode.")
@@ -42,7 +47,7 @@ var prepareEngineLinuxCmd = &cobra.Command{
 	Short: "Validates or updates the flutter engine on this machine for a given platform",
 	Run: func(cmd *cobra.Command, args []string) {
 		initPrepareEngineParameters("linux")
-		subcommandPrepare("linux")
+		subcommandPrepare(cmd.Context(), "linux")
 	},
 }
 
@@ -51,7 +56,7 @@ var prepareEngineDarwinCmd = &cobra.Command{
 	Short: "Validates or updates the flutter engine on this machine for a given platform",
 	Run: func(cmd *cobra.Command, args []string) {
 		initPrepareEngineParameters("darwin")
-		subcommandPrepare("darwin")
+		subcommandPrepare(cmd.Context(), "darwin")
 	},
 }
 
@@ -60,7 +65,7 @@ var prepareEngineWindowsCmd = &cobra.Command{
 	Short: "Validates or updates the flutter engine on this machine for a given platform",
 	Run: func(cmd *cobra.Command, args []string) {
 		initPrepareEngineParameters("windows")
-		subcommandPrepare("windows")
+		subcommandPrepare(cmd.Context(), "windows")
 	},
 }
 
@@ -84,6 +89,10 @@ func validatePrepareEngineParameters(targetOS string) {
 			numberOfPrepareModeFlagsSet++
 		}
 	}
+	if err := build.ValidateArch(prepareArch); err != nil {
+		log.Errorf("%v", err)
+		os.Exit(1)
+	}
 	if numberOfPrepareModeFlagsSet > 1 {
 		log.Errorf("Only one of --debug, --release or --profile can be set at one time")
 		os.Exit(1)
@@ -97,8 +106,19 @@ func validatePrepareEngineParameters(targetOS string) {
 	}
 }
 
-func subcommandPrepare(targetOS string) {
+func subcommandPrepare(ctx context.Context, targetOS string) {
 	for _, mode := range prepareBuildModes {
-		enginecache.ValidateOrUpdateEngine(targetOS, prepareCachePath, prepareEngineVersion, mode)
+		err := hover.PrepareEngine(ctx, hover.BuildOptions{
+			TargetOS:      targetOS,
+			Arch:          prepareArch,
+			Mode:          mode,
+			CachePath:     prepareCachePath,
+			EngineVersion: prepareEngineVersion,
+			EngineMirror:  engineMirror(),
+		})
+		if err != nil {
+			log.Errorf("%v", err)
+			os.Exit(1)
+		}
 	}
 }
@@ -32,7 +32,7 @@ var publishPluginCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		assertInFlutterPluginProject()
 		// check if dir 'go' is tracked
-		goCheckTrackedCmd := exec.Command(build.GitBin(), "ls-files", "--error-unmatch", build.BuildPath)
+		goCheckTrackedCmd := exec.CommandContext(cmd.Context(), gitBin(), "ls-files", "--error-unmatch", build.BuildPath)
 		goCheckTrackedCmd.Stderr = os.Stderr
 		err := goCheckTrackedCmd.Run()
 		if err != nil {
@@ -41,11 +41,11 @@ var publishPluginCmd = &cobra.Command{
 		}
 
 		// check if dir 'go' is clean (all tracked files are committed)
-		goCheckCleanCmd := exec.Command(build.GitBin(), "status", "--untracked-file=no", "--porcelain", build.BuildPath)
+		goCheckCleanCmd := exec.CommandContext(cmd.Context(), gitBin(), "status", "--untracked-file=no", "--porcelain", build.BuildPath)
 		goCheckCleanCmd.Stderr = os.Stderr
 		cleanOut, err := goCheckCleanCmd.Output()
 		if err != nil {
-			log.Errorf("Failed to check if '%s' is clean.", build.BuildPath, err)
+			log.Errorf("Failed to check if '%s' is clean: %v", build.BuildPath, err)
 			os.Exit(1)
 		}
 		if len(cleanOut) != 0 {
@@ -81,7 +81,7 @@ import (
 		path := strings.TrimPrefix(url.Path, "/")
 		path = strings.TrimSuffix(path, "/go")
 		re := regexp.MustCompile(`(\w+)\s+(\S+)` + url.Host + "." + path + ".git")
-		goCheckRemote := exec.Command(build.GitBin(), "remote", "-v")
+		goCheckRemote := exec.CommandContext(cmd.Context(), gitBin(), "remote", "-v")
 		goCheckRemote.Stderr = os.Stderr
 		remoteOut, err := goCheckRemote.Output()
 		if err != nil {
@@ -106,9 +106,9 @@ import (
 		log.Infof("Please run: `%s`", log.Au().Magenta("git tag "+tag))
 		log.Infof("            `%s`", log.Au().Magenta("git push "+match[1]+" "+tag))
 
-		log.Infof(fmt.Sprintf("Let hover run those commands? "))
+		log.Infof("Let hover run those commands? ")
 		if askForConfirmation() {
-			gitTag := exec.Command(build.GitBin(), "tag", tag)
+			gitTag := exec.CommandContext(cmd.Context(), gitBin(), "tag", tag)
 			gitTag.Stderr = os.Stderr
 			gitTag.Stdout = os.Stdout
 			err = gitTag.Run()
@@ -117,7 +117,7 @@ import (
 				os.Exit(1)
 			}
 
-			gitPush := exec.Command(build.GitBin(), "push", match[1], tag)
+			gitPush := exec.CommandContext(cmd.Context(), gitBin(), "push", match[1], tag)
 			gitPush.Stderr = os.Stderr
 			gitPush.Stdout = os.Stdout
 			err = gitPush.Run()
@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
+	"sync/atomic"
+	"syscall"
 
 	"github.com/go-flutter-desktop/hover/internal/log"
 	"github.com/spf13/cobra"
@@ -22,15 +25,6 @@ func init() {
 func initHover() {
 	log.Colorize(colors)
 	log.Verbosity(verbose)
-
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt)
-	go func() {
-		for range c {
-			fmt.Println("")
-			os.Exit(1)
-		}
-	}()
 }
 
 var rootCmd = &cobra.Command{
@@ -39,10 +33,30 @@ var rootCmd = &cobra.Command{
 	Long:  "Hover helps developers to release Flutter applications on desktop.",
 }
 
+// interruptsForwarded is set while the interrupts of the terminal are meant
+// for a child process, such as delve, and don't stop hover.
+var interruptsForwarded atomic.Bool
+
 // Execute executes the rootCmd
 func Execute() {
 	cobra.OnInitialize(initHover)
-	if err := rootCmd.Execute(); err != nil {
+
+	// The context of the commands is cancelled on interrupt, which stops the
+	// external commands and lets hover remove its temporary files before
+	// exiting.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		// a second interrupt exits right away
+		stop()
+		if interruptsForwarded.Load() {
+			return
+		}
+		fmt.Println("")
+		log.Warnf("Interrupted, stopping. Interrupt again to exit right away.")
+	}()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		log.Errorf("Command failed: %v", err)
 		os.Exit(1)
 	}
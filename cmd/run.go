@@ -2,32 +2,67 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
 	"regexp"
 	"runtime"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
-	"github.com/go-flutter-desktop/hover/cmd/packaging"
 	"github.com/go-flutter-desktop/hover/internal/build"
 	"github.com/go-flutter-desktop/hover/internal/config"
+	"github.com/go-flutter-desktop/hover/internal/hotreload"
 	"github.com/go-flutter-desktop/hover/internal/log"
+	"github.com/go-flutter-desktop/hover/internal/packaging"
 	"github.com/go-flutter-desktop/hover/internal/pubspec"
+	"github.com/go-flutter-desktop/hover/internal/vmservice"
+	"github.com/go-flutter-desktop/hover/internal/watch"
+	"github.com/go-flutter-desktop/hover/pkg/hover"
 )
 
 var (
 	runObservatoryPort string
 	runInitialRoute    string
+	runWatch           bool
+	runDevToolsServer  string
+	runTraceTimeline   string
+	runDelve           string
+	runDelvePort       string
 )
 
+// The values of --delve.
+const (
+	// delveInteractive runs delve in the terminal.
+	delveInteractive = "interactive"
+	// delveHeadless runs a delve server, for IDEs and `dlv connect`.
+	delveHeadless = "headless"
+)
+
+// timelineStreams are the streams of the timeline recorded by
+// --trace-timeline, those showing the jank of the frames.
+var timelineStreams = []string{"Dart", "Embedder", "GC"}
+
 func init() {
 	initCompileFlags(runCmd)
 
 	runCmd.Flags().StringVar(&runInitialRoute, "route", "", "Which route to load when running the app.")
 	runCmd.Flags().StringVarP(&runObservatoryPort, "observatory-port", "", "50300", "The observatory port used to connect hover to VM services (hot-reload/debug/..)")
+	runCmd.Flags().BoolVar(&runWatch, "watch", false, "Hot reload when the dart files in lib/ change, rebuild and restart the app when the go files change.")
+	runCmd.Flags().StringVar(&runDevToolsServer, "devtools-server", "http://127.0.0.1:9100", "The DevTools server, started with `dart devtools`, of the DevTools URL printed for debug and profile apps")
+	runCmd.Flags().StringVar(&runTraceTimeline, "trace-timeline", "", "Record the timeline of the app and write it to this file when hover stops the app, by 'q' or an interrupt. Not available in release mode")
+	runCmd.Flags().StringVar(&runDelve, "delve", "", "Run the app under the delve go debugger, in the terminal or, with --delve=headless, as a server for IDEs")
+	runCmd.Flags().Lookup("delve").NoOptDefVal = delveInteractive
+	runCmd.Flags().StringVar(&runDelvePort, "delve-port", "2345", "The port the headless delve server listens on")
 	rootCmd.AddCommand(runCmd)
 }
 
@@ -42,87 +77,576 @@ var runCmd = &cobra.Command{
 		targetOS := runtime.GOOS
 
 		initBuildParameters(targetOS, build.DebugMode)
-		subcommandBuild(targetOS, packaging.NoopTask, []string{
+		if !hostRunsArch(buildOrRunArch) {
+			log.Errorf("The app can't be run for %s on this %s host, use --arch %s or build it with `hover build --arch %s`", buildOrRunArch, runtime.GOARCH, runtime.GOARCH, buildOrRunArch)
+			os.Exit(1)
+		}
+		if runWatch && buildOrRunMode != build.DebugMode {
+			log.Errorf("--watch is only supported in debug mode")
+			os.Exit(1)
+		}
+		if runTraceTimeline != "" && buildOrRunMode == build.ReleaseMode {
+			log.Errorf("The VM service of release builds is disabled, --trace-timeline requires --debug or --profile")
+			os.Exit(1)
+		}
+		validateDelve()
+		vmArguments := []string{
 			"--observatory-port=" + runObservatoryPort,
 			"--enable-service-port-fallback",
 			"--disable-service-auth-codes",
-		})
+		}
+		if buildOrRunDocker {
+			subcommandBuild(cmd.Context(), targetOS, packaging.NoopTask, vmArguments)
+		} else {
+			err := buildApp(cmd.Context(), runBuildOptions(targetOS, vmArguments))
+			if err != nil {
+				log.Errorf("%v", err)
+				os.Exit(1)
+			}
+		}
 
 		log.Infof("Build finished, starting app...")
-		runAndAttach(projectName, targetOS)
+		ctx := cmd.Context()
+		if runDelve == delveInteractive {
+			// the interrupts of the terminal pause the app in delve, they
+			// must not stop hover
+			ctx = context.WithoutCancel(ctx)
+			signal.Notify(make(chan os.Signal, 1), os.Interrupt)
+			interruptsForwarded.Store(true)
+		}
+		runAndReload(ctx, projectName, targetOS, vmArguments)
 	},
 }
 
-func runAndAttach(projectName string, targetOS string) {
-	cmdApp := exec.Command(build.OutputBinaryPath(config.GetConfig().GetExecutableName(projectName), targetOS, buildOrRunMode))
-	cmdApp.Env = append(os.Environ(),
-		"GOFLUTTER_ROUTE="+runInitialRoute)
-	cmdFlutterAttach := exec.Command("flutter", "attach")
+// hostRunsArch returns true when the host can execute the apps built for
+// arch.
+func hostRunsArch(arch string) bool {
+	if arch == runtime.GOARCH {
+		return true
+	}
+	// the amd64 executables are emulated on the arm64 macs and windows PCs
+	return arch == "amd64" && runtime.GOARCH == "arm64" && runtime.GOOS != "linux"
+}
 
-	stdoutApp, err := cmdApp.StdoutPipe()
-	if err != nil {
-		log.Errorf("Unable to create stdout pipe on app: %v", err)
+// validateDelve exits when --delve can't be used.
+func validateDelve() {
+	switch runDelve {
+	case "":
+		return
+	case delveInteractive, delveHeadless:
+	default:
+		log.Errorf("Invalid --delve=%s, must be %s or %s", runDelve, delveInteractive, delveHeadless)
 		os.Exit(1)
 	}
-	stderrApp, err := cmdApp.StderrPipe()
+	if buildOrRunMode != build.DebugMode {
+		log.Errorf("--delve is only supported in debug mode")
+		os.Exit(1)
+	}
+	if buildOrRunDocker {
+		log.Errorf("--delve can't be used with --docker")
+		os.Exit(1)
+	}
+	if runDelve == delveInteractive && runTraceTimeline != "" {
+		log.Errorf("The timeline is written when hover stops the app, the terminal used by delve can't stop it. Use --delve=headless with --trace-timeline")
+		os.Exit(1)
+	}
+	_, err := build.DlvBin()
 	if err != nil {
-		log.Errorf("Unable to create stderr pipe on app: %v", err)
+		log.Errorf("%v", err)
 		os.Exit(1)
 	}
+}
 
-	regexObservatory := regexp.MustCompile(`listening\son\s(http:[^:]*:\d*/)`)
+// runBuildOptions returns the options of the build of the app run on
+// targetOS.
+func runBuildOptions(targetOS string, vmArguments []string) hover.BuildOptions {
+	opts := buildOptions(targetOS, vmArguments)
+	// delve can't step through optimized code
+	opts.DisableGoOptimizations = runDelve != ""
+	return opts
+}
 
-	// asynchronously read the stdout to catch the debug-uri
-	go func(reader io.Reader) {
-		scanner := bufio.NewScanner(reader)
-		for scanner.Scan() {
-			text := scanner.Text()
-			fmt.Println(text)
-			match := regexObservatory.FindStringSubmatch(text)
-			if len(match) == 2 {
-				log.Infof("Connecting hover to '%s' for hot reload", projectName)
-				startHotReloadProcess(cmdFlutterAttach, buildOrRunFlutterTarget, match[1])
-				break
-			}
+// appCommand returns the command running the app, under delve with --delve.
+func appCommand(ctx context.Context, targetOS string) (*exec.Cmd, error) {
+	if runDelve == "" {
+		return exec.CommandContext(ctx, outputBinaryPath(targetOS)), nil
+	}
+	dlvBin, err := build.DlvBin()
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"exec"}
+	if runDelve == delveHeadless {
+		args = append(args,
+			"--headless",
+			"--listen=127.0.0.1:"+runDelvePort,
+			"--api-version=2",
+			"--accept-multiclient",
+		)
+	}
+	cmd := exec.CommandContext(ctx, dlvBin, append(args, outputBinaryPath(targetOS))...)
+	if runtime.GOOS != "windows" {
+		// delve stops the app it debugs when it's interrupted, not when
+		// it's killed
+		cmd.Cancel = func() error {
+			return cmd.Process.Signal(os.Interrupt)
+		}
+		cmd.WaitDelay = 5 * time.Second
+	}
+	return cmd, nil
+}
+
+// runningApp is an instance of the app started by `hover run`.
+type runningApp struct {
+	app *exec.Cmd
+	// exited receives the result of the app once it exits.
+	exited chan error
+	// connected receives the connection to the VM service of the app, once
+	// its observatory is listening.
+	connected chan *vmservice.Client
+	// cancel stops connecting to the VM service.
+	cancel context.CancelFunc
+
+	stopOnce sync.Once
+}
+
+// startApp starts the app and connects to its VM service.
+func startApp(ctx context.Context, projectName, targetOS string) (*runningApp, error) {
+	cmdApp, err := appCommand(ctx, targetOS)
+	if err != nil {
+		return nil, err
+	}
+	cmdApp.Env = append(os.Environ(),
+		"GOFLUTTER_ROUTE="+runInitialRoute)
+	if runTraceTimeline != "" {
+		// the app must outlive an interrupt until its timeline is read
+		ignoreTerminalInterrupts(cmdApp)
+	}
+	connectCtx, cancel := context.WithCancel(ctx)
+	r := &runningApp{
+		app:       cmdApp,
+		exited:    make(chan error, 1),
+		connected: make(chan *vmservice.Client, 1),
+		cancel:    cancel,
+	}
+
+	observatoryURIs := make(chan string, 1)
+	var output sync.WaitGroup
+	if runDelve == delveInteractive {
+		// delve reads its commands from the terminal
+		cmdApp.Stdin = os.Stdin
+		cmdApp.Stdout = os.Stdout
+		cmdApp.Stderr = os.Stderr
+	} else {
+		stdoutApp, err := cmdApp.StdoutPipe()
+		if err != nil {
+			cancel()
+			return nil, errors.Wrap(err, "unable to create stdout pipe on app")
+		}
+		stderrApp, err := cmdApp.StderrPipe()
+		if err != nil {
+			cancel()
+			return nil, errors.Wrap(err, "unable to create stderr pipe on app")
 		}
-		// echo command Stdout to terminal
-		io.Copy(os.Stdout, stdoutApp)
-	}(stdoutApp)
 
-	// Non-blockingly echo command stderr to terminal
-	go io.Copy(os.Stderr, stderrApp)
+		regexObservatory := regexp.MustCompile(`listening\son\s(http:[^:]*:\d*/\S*)`)
+
+		// asynchronously read the stdout to catch the observatory URI, which
+		// differs from the configured one when the port was already in use
+		output.Add(2)
+		go func() {
+			defer output.Done()
+			scanner := bufio.NewScanner(stdoutApp)
+			for scanner.Scan() {
+				text := scanner.Text()
+				fmt.Println(text)
+				match := regexObservatory.FindStringSubmatch(text)
+				if len(match) == 2 {
+					select {
+					case observatoryURIs <- match[1]:
+					default:
+					}
+				}
+			}
+		}()
+
+		// Non-blockingly echo command stderr to terminal
+		go func() {
+			defer output.Done()
+			io.Copy(os.Stderr, stderrApp)
+		}()
+	}
 
 	log.Infof("Running %s in %s mode", projectName, buildOrRunMode.Name)
+	switch runDelve {
+	case delveInteractive:
+		log.Infof("Enter 'continue' in delve to start the app")
+	case delveHeadless:
+		log.Infof("Delve listens on 127.0.0.1:%s, the app starts once a debugger connects and continues it", runDelvePort)
+	}
 	err = cmdApp.Start()
 	if err != nil {
-		log.Errorf("Failed to start app '%s': %v", projectName, err)
-		os.Exit(1)
+		cancel()
+		return nil, errors.Wrapf(err, "failed to start app '%s'", projectName)
+	}
+	go func() {
+		// the pipes must be read to the end before waiting for the app
+		output.Wait()
+		r.exited <- cmdApp.Wait()
+	}()
+	if buildOrRunMode == build.ReleaseMode {
+		// the observatory of release builds is disabled
+		return r, nil
+	}
+	pid := cmdApp.Process.Pid
+	if runDelve != "" {
+		// the app is a child of delve
+		pid = 0
+	}
+	go func() {
+		client, err := connectVMService(connectCtx, observatoryURIs, pid)
+		if err != nil {
+			if connectCtx.Err() == nil {
+				log.Warnf("Failed to connect to the VM service of '%s': %v", projectName, err)
+			}
+			return
+		}
+		r.connected <- client
+	}()
+	return r, nil
+}
+
+// connectVMService connects to the VM service of the app, on the URI printed
+// by the app. The configured observatory port is used when the app doesn't
+// print it, the VM service must then belong to the app of process pid, unless
+// pid is 0.
+func connectVMService(ctx context.Context, observatoryURIs <-chan string, pid int) (*vmservice.Client, error) {
+	if runDelve == "" {
+		// under delve, the app waits for the debugger
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+	}
+	var uri string
+	select {
+	case uri = <-observatoryURIs:
+	case <-time.After(10 * time.Second):
+		// the output of the app isn't read under interactive delve
+		uri = vmservice.ObservatoryURI(runObservatoryPort)
+	case <-ctx.Done():
+		return nil, errors.New("the app didn't print its observatory URI")
+	}
+	for {
+		client, err := vmservice.Dial(ctx, uri)
+		if err == nil {
+			err = checkVMPid(ctx, client, pid)
+			if err != nil {
+				client.Close()
+				return nil, err
+			}
+			return client, nil
+		}
+		select {
+		case uri = <-observatoryURIs:
+		case <-time.After(250 * time.Millisecond):
+		case <-ctx.Done():
+			return nil, err
+		}
 	}
+}
+
+// checkVMPid returns an error when the VM service of client doesn't belong to
+// the process pid, e.g. when another app holds the observatory port.
+func checkVMPid(ctx context.Context, client *vmservice.Client, pid int) error {
+	if pid == 0 {
+		return nil
+	}
+	vm, err := client.GetVM(ctx)
+	if err != nil {
+		return err
+	}
+	if vm.Pid != pid {
+		return errors.Errorf("the VM service at %s belongs to another process (pid %d), not the app (pid %d)", client.URI(), vm.Pid, pid)
+	}
+	return nil
+}
+
+// stop kills the app, and waits for it to exit. The exit is only received
+// once, the later calls return immediately.
+func (r *runningApp) stop() {
+	r.stopOnce.Do(func() {
+		r.cancel()
+		if runDelve != "" && runtime.GOOS != "windows" {
+			// delve stops the app it debugs when it's interrupted
+			r.app.Cancel()
+			select {
+			case <-r.exited:
+				return
+			case <-time.After(r.app.WaitDelay):
+			}
+		}
+		r.app.Process.Kill()
+		<-r.exited
+	})
+}
+
+// readCommands sends the lines read from reader, until it's closed.
+func readCommands(reader io.Reader) <-chan string {
+	commands := make(chan string)
+	go func() {
+		defer close(commands)
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			commands <- strings.TrimSpace(scanner.Text())
+		}
+	}()
+	return commands
+}
 
-	err = cmdApp.Wait()
+// reportReload prints the outcome of a hot reload or a hot restart.
+func reportReload(action string, report *hotreload.Report, err error) {
 	if err != nil {
-		log.Errorf("App '%s' exited with error: %v", projectName, err)
-		os.Exit(cmdApp.ProcessState.ExitCode())
+		if compileErr, ok := errors.Cause(err).(*hotreload.CompileError); ok {
+			log.Errorf("%s failed, the dart code doesn't compile:", action)
+			for _, diagnostic := range compileErr.Diagnostics {
+				fmt.Fprintln(os.Stderr, diagnostic)
+			}
+			return
+		}
+		log.Errorf("%s failed: %v", action, err)
+		return
 	}
-	log.Infof("App '%s' exited.", projectName)
-	log.Printf("Closing the flutter attach sub process..")
-	cmdFlutterAttach.Wait()
-	os.Exit(0)
+	if report == nil {
+		return
+	}
+	log.Infof("%s of %d changed file(s) done in %s (compile %s, reload %s)", action, report.Files,
+		(report.Compile + report.Reload).Round(time.Millisecond),
+		report.Compile.Round(time.Millisecond),
+		report.Reload.Round(time.Millisecond))
 }
 
-func startHotReloadProcess(cmdFlutterAttach *exec.Cmd, buildTargetMainDart string, uri string) {
-	cmdFlutterAttach.Stdin = os.Stdin
-	cmdFlutterAttach.Stdout = os.Stdout
-	cmdFlutterAttach.Stderr = os.Stderr
+// startReloader starts the compiler hot reloading the app whose flutter
+// assets are in assetDirectory.
+func startReloader(ctx context.Context, assetDirectory string) (*hotreload.Reloader, error) {
+	// the sources recompiled on hot reload must see the constants of the build
+	dartDefines, err := build.MergeDartDefines(config.GetConfig().DartDefines, buildOrRunDartDefineFiles, buildOrRunDartDefines)
+	if err != nil {
+		return nil, err
+	}
+	return hotreload.NewReloader(ctx, hotreload.Options{
+		Target:         buildOrRunFlutterTarget,
+		DartDefines:    dartDefines,
+		AssetDirectory: assetDirectory,
+	})
+}
 
-	cmdFlutterAttach.Args = []string{
-		"flutter", "attach",
-		"--target", buildTargetMainDart,
-		"--device-id", "flutter-tester",
-		"--debug-uri", uri,
+// writeTimeline writes the timeline recorded by the app to path.
+func writeTimeline(ctx context.Context, client *vmservice.Client, path string) error {
+	timeline, err := client.GetVMTimeline(ctx)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(path, timeline, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write the timeline to %s", path)
 	}
-	err := cmdFlutterAttach.Start()
+	return nil
+}
+
+// runAndReload runs the app and hot reloads it on the commands read from the
+// terminal. With --watch, a change of the dart code is hot reloaded and a
+// change of the go code rebuilds the go binary and restarts the app.
+func runAndReload(ctx context.Context, projectName, targetOS string, vmArguments []string) {
+	// the app is stopped by hover once ctx is cancelled, not killed right
+	// away, its timeline can be read before
+	appCtx := context.WithoutCancel(ctx)
+	r, err := startApp(appCtx, projectName, targetOS)
 	if err != nil {
-		log.Warnf("The command 'flutter attach' failed: %v hot reload disabled", err)
+		log.Errorf("%v", err)
+		os.Exit(1)
+	}
+
+	// the compiler outlives the restarts of the app
+	var reloader *hotreload.Reloader
+	if buildOrRunMode == build.DebugMode {
+		reloader, err = startReloader(ctx, filepath.Join(outputDirectoryPath(targetOS), "flutter_assets"))
+		if err != nil {
+			log.Warnf("Failed to start the dart compiler: %v hot reload disabled", err)
+		}
+	}
+	exit := func(code int) {
+		if reloader != nil {
+			reloader.Close()
+		}
+		os.Exit(code)
+	}
+	hotReloadEnabled := func() bool {
+		switch {
+		case buildOrRunMode != build.DebugMode:
+			log.Warnf("Hot reload is only available in debug mode")
+		case reloader == nil:
+			log.Warnf("Hot reload is disabled")
+		}
+		return reloader != nil
+	}
+	reload := func() {
+		if !hotReloadEnabled() {
+			return
+		}
+		report, err := reloader.Reload(ctx)
+		reportReload("Hot reload", report, err)
+	}
+
+	var dartChanges, goChanges <-chan []string
+	var commands <-chan string
+	if runDelve == delveInteractive {
+		// the terminal belongs to delve, which can't be restarted by hover
+		if runWatch {
+			dartChanges = watch.Poll(ctx, 500*time.Millisecond, []string{hotreload.SourceDirectory}, hotreload.DartSources)
+			log.Infof("Watching %s/ for changes, the go changes need a restart of delve", hotreload.SourceDirectory)
+		}
+	} else if runWatch {
+		dartChanges = watch.Poll(ctx, 500*time.Millisecond, []string{hotreload.SourceDirectory}, hotreload.DartSources)
+		goChanges = watch.Poll(ctx, 500*time.Millisecond, []string{build.BuildPath}, func(path string, info os.FileInfo) bool {
+			if info.IsDir() {
+				// go/build contains the outputs of the build
+				return path != filepath.Join(build.BuildPath, "build")
+			}
+			name := info.Name()
+			return strings.HasSuffix(name, ".go") || name == "go.mod" || name == "go.sum"
+		})
+		log.Infof("Watching %s/ and %s/ for changes", hotreload.SourceDirectory, build.BuildPath)
+	}
+	if runDelve != delveInteractive {
+		commands = readCommands(os.Stdin)
+	}
+
+	// stopped is set when the app was stopped for a build which failed, the
+	// exit of a stopped app was already received by stop.
+	stopped := false
+	// client is the connection to the VM service of the running app
+	var client *vmservice.Client
+	// stopApp stops the app and closes its connection, the connection to the
+	// next instance is received on r.connected
+	stopApp := func() {
+		r.stop()
+		if client != nil {
+			client.Close()
+			client = nil
+		}
+	}
+	// quit writes the timeline of the app, stops it and exits
+	quit := func(code int) {
+		if runTraceTimeline != "" {
+			if client == nil {
+				log.Errorf("No timeline was written, hover isn't connected to the VM service of the app")
+			} else {
+				timelineCtx, cancel := context.WithTimeout(appCtx, 30*time.Second)
+				err := writeTimeline(timelineCtx, client, runTraceTimeline)
+				cancel()
+				if err != nil {
+					log.Errorf("No timeline was written, failed to capture it: %v", err)
+				} else {
+					log.Infof("Timeline written to %s, open it in DevTools or https://ui.perfetto.dev", runTraceTimeline)
+				}
+			}
+		}
+		stopApp()
+		log.Infof("App '%s' exited.", projectName)
+		exit(code)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			quit(1)
+		case err := <-r.exited:
+			if runTraceTimeline != "" {
+				log.Errorf("No timeline was written, the app exited before hover could read it")
+			}
+			if err != nil {
+				log.Errorf("App '%s' exited with error: %v", projectName, err)
+				exit(r.app.ProcessState.ExitCode())
+			}
+			log.Infof("App '%s' exited.", projectName)
+			exit(0)
+		case client = <-r.connected:
+			devToolsURL, err := vmservice.DevToolsURL(runDevToolsServer, client.URI())
+			if err == nil {
+				log.Infof("The DevTools of '%s' are available at %s once the server is started with `dart devtools`", projectName, devToolsURL)
+			}
+			if runTraceTimeline != "" {
+				err = client.SetVMTimelineFlags(ctx, timelineStreams)
+				if err != nil {
+					log.Warnf("Failed to record the timeline: %v", err)
+				} else {
+					log.Infof("Recording the timeline, it's written to %s when quitting with 'q' or interrupting hover", runTraceTimeline)
+				}
+			}
+			if reloader == nil {
+				if commands != nil {
+					log.Infof("Enter 'q' to quit")
+				}
+				continue
+			}
+			if commands == nil {
+				// the terminal is used by delve
+				log.Infof("Connected hover to '%s' for hot reload", projectName)
+			} else {
+				log.Infof("Connected hover to '%s' for hot reload, enter 'r' to hot reload, 'R' to hot restart, 'q' to quit", projectName)
+			}
+			report, err := reloader.Attach(ctx, client)
+			reportReload("Hot restart", report, err)
+		case command, ok := <-commands:
+			if !ok {
+				// stdin was closed
+				commands = nil
+				continue
+			}
+			switch command {
+			case "r":
+				reload()
+			case "R":
+				if !hotReloadEnabled() {
+					continue
+				}
+				report, err := reloader.Restart(ctx)
+				reportReload("Hot restart", report, err)
+			case "q":
+				quit(0)
+			}
+		case paths := <-dartChanges:
+			log.Infof("%s changed, hot reloading", strings.Join(paths, ", "))
+			reload()
+		case paths := <-goChanges:
+			log.Infof("%s changed, rebuilding the go binary", strings.Join(paths, ", "))
+			if runtime.GOOS == "windows" && !stopped {
+				// a running executable can't be replaced on windows
+				stopApp()
+				stopped = true
+			}
+			opts := runBuildOptions(targetOS, vmArguments)
+			opts.SkipFlutter = true
+			err := hover.Build(ctx, opts)
+			if err != nil {
+				log.Errorf("%v", err)
+				if stopped {
+					log.Warnf("Fix the error to start the app again")
+				} else {
+					log.Warnf("The app keeps running with the previous go binary")
+				}
+				continue
+			}
+			log.Infof("Restarting %s", projectName)
+			if !stopped {
+				stopApp()
+			}
+			r, err = startApp(appCtx, projectName, targetOS)
+			if err != nil {
+				log.Errorf("%v", err)
+				exit(1)
+			}
+			stopped = false
+		}
 	}
 }
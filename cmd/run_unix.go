@@ -0,0 +1,14 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// ignoreTerminalInterrupts starts cmd in its own process group, the
+// interrupts of the terminal are then only received by hover.
+func ignoreTerminalInterrupts(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
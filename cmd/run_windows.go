@@ -0,0 +1,12 @@
+package cmd
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// ignoreTerminalInterrupts starts cmd in its own process group, the
+// interrupts of the console are then only received by hover.
+func ignoreTerminalInterrupts(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	copy "github.com/otiai10/copy"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/go-flutter-desktop/hover/internal/build"
+	"github.com/go-flutter-desktop/hover/internal/log"
+	"github.com/go-flutter-desktop/hover/internal/packaging"
+	"github.com/go-flutter-desktop/hover/pkg/hover"
+)
+
+func init() {
+	initCompileFlags(verifyReproducibleCmd)
+	rootCmd.AddCommand(verifyReproducibleCmd)
+}
+
+var verifyReproducibleCmd = &cobra.Command{
+	Use:   "verify-reproducible <target>...",
+	Short: "Build the targets twice with --reproducible and compare the outputs",
+	Long: "Build the targets twice with --reproducible and compare the outputs.\n" +
+		"For example: `hover verify-reproducible linux linux-deb`.\n" +
+		"The second build runs in a copy of the project, so that the outputs don't depend on the project path.\n" +
+		"Every file of the build output and of the packages must be identical, the differences are listed otherwise.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("requires at least one build target, e.g. linux or linux-deb")
+		}
+		return buildTargetArgs(cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		assertHoverInitialized()
+		if buildOrRunDocker {
+			log.Errorf("verify-reproducible doesn't support --docker builds")
+			os.Exit(1)
+		}
+		for _, name := range args {
+			target, _ := findBuildTarget(name)
+			assertPackagingTaskUsable(target.packagingTask)
+		}
+		buildReproducible = true
+		buildOrRunForce = true
+
+		first, err := buildReproducibleTargets(cmd.Context(), args)
+		if err != nil {
+			log.Errorf("%v", err)
+			os.Exit(1)
+		}
+		second, err := buildReproducibleTargetsInCopy(cmd.Context(), args)
+		if err != nil {
+			log.Errorf("%v", err)
+			os.Exit(1)
+		}
+
+		var paths []string
+		for path := range first {
+			paths = append(paths, path)
+		}
+		for path := range second {
+			if _, ok := first[path]; !ok {
+				paths = append(paths, path)
+			}
+		}
+		sort.Strings(paths)
+		differences := 0
+		for _, path := range paths {
+			firstSum, inFirst := first[path]
+			secondSum, inSecond := second[path]
+			switch {
+			case !inFirst:
+				log.Errorf("%s: only in the second build", path)
+			case !inSecond:
+				log.Errorf("%s: only in the first build", path)
+			case firstSum != secondSum:
+				log.Errorf("%s: differs, sha256 %s and %s", path, firstSum, secondSum)
+			default:
+				continue
+			}
+			differences++
+		}
+		if differences > 0 {
+			log.Errorf("%d of %d files aren't reproducible", differences, len(paths))
+			os.Exit(1)
+		}
+		log.Infof("The %d files of the two builds are identical", len(paths))
+	},
+}
+
+// buildReproducibleTargetsInCopy builds and packages the targets in a copy of
+// the project, and returns the SHA-256 checksums of their files by path.
+func buildReproducibleTargetsInCopy(ctx context.Context, targetNames []string) (map[string]string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get working dir")
+	}
+	// the copy is next to the project, the relative paths of the path
+	// dependencies and of the go.mod replace directives stay valid
+	copyPath, err := ioutil.TempDir(filepath.Dir(wd), "."+filepath.Base(wd)+"-reproducible-")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the copy of the project")
+	}
+	defer os.RemoveAll(copyPath)
+	skipped := map[string]bool{
+		filepath.Join(wd, ".git"):                   true,
+		filepath.Join(wd, "build"):                  true,
+		filepath.Join(wd, build.BuildPath, "build"): true,
+	}
+	err = copy.Copy(wd, copyPath, copy.Options{
+		Skip: func(info os.FileInfo, src, dest string) (bool, error) {
+			return skipped[src], nil
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to copy the project")
+	}
+	log.Infof("Building the copy of the project in %s", copyPath)
+	err = os.Chdir(copyPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to change to the copy of the project")
+	}
+	defer os.Chdir(wd)
+	return buildReproducibleTargets(ctx, targetNames)
+}
+
+// buildReproducibleTargets builds and packages the targets, and returns the
+// SHA-256 checksums of their files by path.
+func buildReproducibleTargets(ctx context.Context, targetNames []string) (map[string]string, error) {
+	checksums := make(map[string]string)
+	builtOSs := make(map[string]bool)
+	var bundleOS string
+	for _, name := range targetNames {
+		target, _ := findBuildTarget(name)
+		targetOS := target.targetOS
+		if !builtOSs[targetOS] {
+			initBuildParameters(targetOS, build.ReleaseMode)
+			opts := buildOptions(targetOS, nil)
+			opts.FlutterBundleOS = bundleOS
+			err := buildApp(ctx, opts)
+			if err != nil {
+				return nil, errors.Wrapf(err, "building app for %s failed", targetOS)
+			}
+			builtOSs[targetOS] = true
+			if bundleOS == "" {
+				bundleOS = targetOS
+			}
+		}
+		artifact, err := build.OutputDirectoryPath(targetOS, buildOrRunArch, buildOrRunMode)
+		if err != nil {
+			return nil, err
+		}
+		if target.packagingTask != packaging.NoopTask {
+			artifact, err = hover.Package(ctx, target.packagingTask, buildOptions(targetOS, nil))
+			if err != nil {
+				return nil, errors.Wrapf(err, "packaging app for %s failed", target.packagingTask.Name())
+			}
+		}
+		artifacts, err := build.NewReportArtifacts(artifact)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range artifacts {
+			checksums[a.Path] = a.SHA256
+		}
+	}
+	return checksums, nil
+}
@@ -0,0 +1,56 @@
+package aot
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// FakeRunner is a Runner for tests. It records the commands instead of
+// running them, and creates the files named by their --output-dill=, --elf=
+// and --save-debugging-info= arguments so that the steps after it find their
+// inputs.
+type FakeRunner struct {
+	// FailTool, when set, makes Run fail for the commands of the tool at
+	// this path.
+	FailTool string
+
+	mu       sync.Mutex
+	commands [][]string
+}
+
+// Path returns hostPath unchanged.
+func (r *FakeRunner) Path(hostPath string) string {
+	return hostPath
+}
+
+// Run records the command and creates its output file.
+func (r *FakeRunner) Run(ctx context.Context, name string, args ...string) error {
+	r.mu.Lock()
+	r.commands = append(r.commands, append([]string{name}, args...))
+	r.mu.Unlock()
+	if name == r.FailTool {
+		return errors.Errorf("%s failed", name)
+	}
+	for _, arg := range args {
+		for _, prefix := range []string{"--output-dill=", "--elf=", "--save-debugging-info="} {
+			if strings.HasPrefix(arg, prefix) {
+				err := os.WriteFile(strings.TrimPrefix(arg, prefix), []byte(name), 0644)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Commands returns the commands run so far.
+func (r *FakeRunner) Commands() [][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]string(nil), r.commands...)
+}
@@ -0,0 +1,177 @@
+package aot
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/go-flutter-desktop/hover/internal/darwinhacks"
+	"github.com/go-flutter-desktop/hover/internal/version"
+)
+
+// Runner runs the tools of an engine build for a target OS. The tools of the
+// engine run on the target OS, a Runner for another OS than the host runs them
+// in an emulation layer or a container.
+type Runner interface {
+	// Path returns the path of the host file hostPath as seen by the tools.
+	Path(hostPath string) string
+	// Run runs the tool at path name, as returned by Path, with args.
+	Run(ctx context.Context, name string, args ...string) error
+}
+
+// NewRunner returns the Runner for the tools of targetOS engines on this host,
+// or an error when the host lacks what's needed to run them.
+func NewRunner(targetOS string) (Runner, error) {
+	if targetOS == runtime.GOOS {
+		return NativeRunner{}, nil
+	}
+	switch {
+	case targetOS == "windows":
+		if path, err := exec.LookPath("wine"); err != nil || len(path) == 0 {
+			return nil, errors.Errorf("to cross-compile AOT apps for windows on %s install wine from your package manager or https://www.winehq.org/", runtime.GOOS)
+		}
+		return wineRunner{}, nil
+	case targetOS == "darwin" && runtime.GOOS == "linux":
+		if path, err := exec.LookPath("darling"); err != nil || len(path) == 0 {
+			return nil, errors.Errorf("to cross-compile AOT apps for darwin on %s install darling from your package manager or https://www.darlinghq.org/", runtime.GOOS)
+		}
+		return darlingRunner{}, nil
+	case targetOS == "linux" && runtime.GOOS == "darwin":
+		if path, err := exec.LookPath("docker"); err != nil || len(path) == 0 {
+			return nil, errors.Errorf("to cross-compile AOT apps for linux on %s install docker from https://docs.docker.com/get-docker/", runtime.GOOS)
+		}
+		return newDockerRunner(), nil
+	case targetOS == "linux":
+		return nil, errors.Errorf("AOT apps for linux can't be cross-compiled on %s, build them in the hover docker image", runtime.GOOS)
+	default:
+		return nil, errors.New("AOT builds currently only work on their host OS, use the JIT release mode instead")
+	}
+}
+
+// NativeRunner runs the tools directly on the host.
+type NativeRunner struct{}
+
+// Path returns hostPath unchanged.
+func (NativeRunner) Path(hostPath string) string {
+	return hostPath
+}
+
+// Run runs the tool with its output sent to the standard output and error.
+func (NativeRunner) Run(ctx context.Context, name string, args ...string) error {
+	return runCommand(ctx, name, args...)
+}
+
+// wineRunner runs windows tools on linux and darwin hosts.
+type wineRunner struct{}
+
+func (wineRunner) Path(hostPath string) string {
+	return hostPath
+}
+
+func (wineRunner) Run(ctx context.Context, name string, args ...string) error {
+	return runCommand(ctx, "wine", append([]string{name}, args...)...)
+}
+
+// darlingRunner runs darwin tools on linux hosts. Darling mounts the host
+// filesystem under /Volumes/SystemRoot, the paths in the package files of the
+// app are rewritten for the duration of the command.
+type darlingRunner struct{}
+
+func (darlingRunner) Path(hostPath string) string {
+	return darwinhacks.RewriteDarlingPath(true, hostPath)
+}
+
+func (darlingRunner) Run(ctx context.Context, name string, args ...string) error {
+	err := darwinhacks.ChangePackagesFilePath(true)
+	if err != nil {
+		return err
+	}
+	err = runCommand(ctx, "darling", append([]string{"shell", name}, args...)...)
+	// Change back paths even if the command failed
+	if err := darwinhacks.ChangePackagesFilePath(false); err != nil {
+		return err
+	}
+	return err
+}
+
+// dockerRunner runs linux tools in the hover docker image. The directories of
+// the files given to Path, the working directory and the pub cache are
+// mounted at the same paths in the container, so that the absolute paths in
+// the package files of the app stay valid.
+type dockerRunner struct {
+	image  string
+	mounts map[string]bool
+}
+
+func newDockerRunner() *dockerRunner {
+	hoverVersion := version.HoverVersion()
+	if hoverVersion == "(devel)" {
+		hoverVersion = "latest"
+	}
+	return &dockerRunner{
+		image:  "goflutter/hover:" + hoverVersion,
+		mounts: make(map[string]bool),
+	}
+}
+
+func (r *dockerRunner) Path(hostPath string) string {
+	absPath, err := filepath.Abs(hostPath)
+	if err != nil {
+		return hostPath
+	}
+	r.mounts[filepath.Dir(absPath)] = true
+	return filepath.ToSlash(absPath)
+}
+
+func (r *dockerRunner) Run(ctx context.Context, name string, args ...string) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return errors.Wrap(err, "failed to get working directory")
+	}
+	r.mounts[wd] = true
+	if pubCache := pubCachePath(); pubCache != "" {
+		r.mounts[pubCache] = true
+	}
+	var mounts []string
+	for mount := range r.mounts {
+		mounts = append(mounts, mount)
+	}
+	sort.Strings(mounts)
+
+	dockerArgs := []string{"run", "--rm", "--workdir", filepath.ToSlash(wd)}
+	for _, mount := range mounts {
+		dockerArgs = append(dockerArgs, "--mount", "type=bind,source="+mount+",target="+filepath.ToSlash(mount))
+	}
+	dockerArgs = append(dockerArgs, "--entrypoint", name, r.image)
+	dockerArgs = append(dockerArgs, args...)
+	return runCommand(ctx, "docker", dockerArgs...)
+}
+
+// pubCachePath returns the path of the pub cache used by the flutter tool, or
+// an empty string when there is none.
+func pubCachePath() string {
+	path := os.Getenv("PUB_CACHE")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		path = filepath.Join(home, ".pub-cache")
+	}
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+func runCommand(ctx context.Context, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
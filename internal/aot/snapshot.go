@@ -0,0 +1,135 @@
+package aot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/go-flutter-desktop/hover/internal/log"
+)
+
+// Snapshot describes the inputs of an AOT snapshot build. The tool paths are
+// host paths, they are translated by the Runner.
+type Snapshot struct {
+	// Dart is the dart executable of the engine.
+	Dart string
+	// FrontendServer is the frontend_server.dart.snapshot of the engine.
+	FrontendServer string
+	// FlutterPatchedSdk is the flutter_patched_sdk directory of the engine.
+	FlutterPatchedSdk string
+	// GenSnapshot is the gen_snapshot executable of the engine.
+	GenSnapshot string
+	// Packages is the package file of the app, relative to the working
+	// directory.
+	Packages string
+	// Target is the main dart file of the app.
+	Target string
+	// DartDefines are the KEY=VALUE compile-time constants of the dart code.
+	DartDefines []string
+	// OutputDirectory is the directory libapp.so is written to.
+	OutputDirectory string
+	// Strip removes the debugging information from the snapshot.
+	Strip bool
+	// Obfuscate renames the dart symbols in the snapshot.
+	Obfuscate bool
+	// DebugInfoPath, when set, is the file the debugging information of the
+	// snapshot is saved to. It's needed to symbolicate the stack traces of
+	// stripped or obfuscated snapshots.
+	DebugInfoPath string
+	// ObfuscationMapPath, when set, is the file the map of the obfuscated
+	// dart symbols to their names is saved to. It's only used with Obfuscate.
+	ObfuscationMapPath string
+}
+
+// ELFPath returns the path of the libapp.so snapshot built for s.
+func (s Snapshot) ELFPath() string {
+	return filepath.Join(s.OutputDirectory, "libapp.so")
+}
+
+func (s Snapshot) kernelPath() string {
+	return filepath.Join(s.OutputDirectory, "kernel_snapshot.dill")
+}
+
+// KernelCommand returns the command compiling the dart code of the app into a
+// kernel snapshot, with the paths translated by runner.
+func (s Snapshot) KernelCommand(runner Runner) []string {
+	command := []string{
+		runner.Path(s.Dart),
+		runner.Path(s.FrontendServer),
+		"--sdk-root=" + runner.Path(s.FlutterPatchedSdk),
+		"--target=flutter",
+		"--aot",
+		"--tfa",
+		"-Ddart.vm.product=true",
+	}
+	for _, define := range s.DartDefines {
+		command = append(command, "-D"+define)
+	}
+	return append(command,
+		"--packages="+s.Packages,
+		"--output-dill="+runner.Path(s.kernelPath()),
+		s.Target,
+	)
+}
+
+// ELFCommand returns the command compiling the kernel snapshot into the
+// libapp.so ELF snapshot, with the paths translated by runner.
+func (s Snapshot) ELFCommand(runner Runner) []string {
+	command := []string{
+		runner.Path(s.GenSnapshot),
+		"--lazy-async-stacks",
+		"--deterministic",
+		"--snapshot_kind=app-aot-elf",
+		"--elf=" + runner.Path(s.ELFPath()),
+	}
+	if s.Strip {
+		command = append(command, "--strip")
+	}
+	if s.DebugInfoPath != "" {
+		command = append(command,
+			"--dwarf-stack-traces",
+			"--resolve-dwarf-paths",
+			"--save-debugging-info="+runner.Path(s.DebugInfoPath),
+		)
+	}
+	if s.Obfuscate {
+		command = append(command, "--obfuscate")
+		if s.ObfuscationMapPath != "" {
+			command = append(command, "--save-obfuscation-map="+runner.Path(s.ObfuscationMapPath))
+		}
+	}
+	return append(command, runner.Path(s.kernelPath()))
+}
+
+// Build compiles the dart code of the app into the libapp.so ELF snapshot,
+// running the engine tools with runner.
+func Build(ctx context.Context, runner Runner, s Snapshot) error {
+	log.Infof("Generating kernel snapshot")
+	kernelCommand := s.KernelCommand(runner)
+	err := runner.Run(ctx, kernelCommand[0], kernelCommand[1:]...)
+	if err != nil {
+		return errors.Wrap(err, "generating kernel snapshot failed")
+	}
+	for _, debugPath := range []string{s.DebugInfoPath, s.ObfuscationMapPath} {
+		if debugPath == "" {
+			continue
+		}
+		err = os.MkdirAll(filepath.Dir(debugPath), 0755)
+		if err != nil {
+			return errors.Wrap(err, "failed to create the debugging information directory")
+		}
+	}
+	log.Infof("Generating ELF snapshot")
+	elfCommand := s.ELFCommand(runner)
+	err = runner.Run(ctx, elfCommand[0], elfCommand[1:]...)
+	if err != nil {
+		return errors.Wrap(err, "generating AOT snapshot failed")
+	}
+	err = os.Remove(s.kernelPath())
+	if err != nil {
+		return errors.Wrap(err, "failed to remove kernel_snapshot.dill")
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package aot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testSnapshot(t *testing.T) Snapshot {
+	return Snapshot{
+		Dart:              "/engine/dart",
+		FrontendServer:    "/engine/gen/frontend_server.dart.snapshot",
+		FlutterPatchedSdk: "/engine/flutter_patched_sdk",
+		GenSnapshot:       "/engine/gen_snapshot",
+		Packages:          ".packages",
+		Target:            "lib/main_desktop.dart",
+		OutputDirectory:   t.TempDir(),
+	}
+}
+
+func TestBuild(t *testing.T) {
+	snapshot := testSnapshot(t)
+	snapshot.Strip = true
+	snapshot.DartDefines = []string{"API_URL=https://example.com"}
+	runner := &FakeRunner{}
+	err := Build(context.Background(), runner, snapshot)
+	require.NoError(t, err)
+
+	commands := runner.Commands()
+	require.Len(t, commands, 2)
+	require.Equal(t, "/engine/dart", commands[0][0])
+	require.Contains(t, commands[0], "--output-dill="+filepath.Join(snapshot.OutputDirectory, "kernel_snapshot.dill"))
+	require.Contains(t, commands[0], "-DAPI_URL=https://example.com")
+	require.Equal(t, "lib/main_desktop.dart", commands[0][len(commands[0])-1])
+	require.Equal(t, "/engine/gen_snapshot", commands[1][0])
+	require.Contains(t, commands[1], "--strip")
+	require.Equal(t, filepath.Join(snapshot.OutputDirectory, "kernel_snapshot.dill"), commands[1][len(commands[1])-1])
+
+	require.FileExists(t, snapshot.ELFPath())
+	_, err = os.Stat(filepath.Join(snapshot.OutputDirectory, "kernel_snapshot.dill"))
+	require.True(t, os.IsNotExist(err), "the kernel snapshot should be removed")
+}
+
+func TestBuildKernelFailure(t *testing.T) {
+	snapshot := testSnapshot(t)
+	runner := &FakeRunner{FailTool: "/engine/dart"}
+	err := Build(context.Background(), runner, snapshot)
+	require.Error(t, err)
+	require.Len(t, runner.Commands(), 1)
+	require.NoFileExists(t, snapshot.ELFPath())
+}
+
+func TestDarlingPaths(t *testing.T) {
+	snapshot := testSnapshot(t)
+	command := snapshot.ELFCommand(darlingRunner{})
+	require.Equal(t, "/Volumes/SystemRoot/engine/gen_snapshot", command[0])
+	require.Contains(t, command, "--elf="+filepath.Join("/Volumes/SystemRoot", snapshot.OutputDirectory, "libapp.so"))
+	require.NotContains(t, command, "--strip")
+}
+
+func TestBuildSplitDebugInfo(t *testing.T) {
+	snapshot := testSnapshot(t)
+	snapshot.Obfuscate = true
+	symbolsPath := filepath.Join(t.TempDir(), "symbols", "1.0.0")
+	snapshot.DebugInfoPath = filepath.Join(symbolsPath, "app.linux-amd64.symbols")
+	snapshot.ObfuscationMapPath = filepath.Join(symbolsPath, "app.linux-amd64.obfuscation.json")
+	runner := &FakeRunner{}
+	err := Build(context.Background(), runner, snapshot)
+	require.NoError(t, err)
+
+	command := runner.Commands()[1]
+	require.Contains(t, command, "--obfuscate")
+	require.Contains(t, command, "--save-debugging-info="+snapshot.DebugInfoPath)
+	require.Contains(t, command, "--save-obfuscation-map="+snapshot.ObfuscationMapPath)
+	require.FileExists(t, snapshot.DebugInfoPath)
+}
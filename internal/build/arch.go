@@ -0,0 +1,46 @@
+package build
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultArch is the architecture hover builds for when no `--arch` flag is
+// provided.
+const DefaultArch = "amd64"
+
+// SupportedArchs lists the GOARCH values hover is able to build for.
+var SupportedArchs = []string{"amd64", "arm64"}
+
+// ValidateArch returns an error when the architecture isn't supported.
+func ValidateArch(arch string) error {
+	for _, supportedArch := range SupportedArchs {
+		if arch == supportedArch {
+			return nil
+		}
+	}
+	return errors.Errorf("architecture %s is not supported, supported architectures are: %v", arch, SupportedArchs)
+}
+
+// FlutterArch returns the name flutter uses in its artifact names for a
+// given GOARCH. (amd64 -> x64)
+func FlutterArch(arch string) string {
+	switch arch {
+	case "amd64":
+		return "x64"
+	default:
+		return arch
+	}
+}
+
+// TargetName returns the name used for the build output directories and the
+// engine cache of a targetOS, architecture and build mode combination.
+// eg: linux-release or linux-arm64-release
+// The default architecture is omitted to keep the existing directory layout.
+func TargetName(targetOS, arch string, mode Mode) string {
+	if arch == DefaultArch || arch == "" {
+		return fmt.Sprintf("%s-%s", targetOS, mode.Name)
+	}
+	return fmt.Sprintf("%s-%s-%s", targetOS, arch, mode.Name)
+}
@@ -1,30 +1,28 @@
 package build
 
 import (
-	"os"
 	"os/exec"
 	"sync"
 
-	"github.com/go-flutter-desktop/hover/internal/log"
+	"github.com/pkg/errors"
 )
 
 type binLookup struct {
 	Name                string
 	InstallInstructions string
 	fullPath            string
+	err                 error
 	once                sync.Once
 }
 
-func (b *binLookup) FullPath() string {
+func (b *binLookup) FullPath() (string, error) {
 	b.once.Do(func() {
-		var err error
-		b.fullPath, err = exec.LookPath(b.Name)
-		if err != nil {
-			log.Errorf("Failed to lookup `%s` executable: %s. %s", b.Name, err, b.InstallInstructions)
-			os.Exit(1)
+		b.fullPath, b.err = exec.LookPath(b.Name)
+		if b.err != nil {
+			b.err = errors.Wrapf(b.err, "failed to lookup `%s` executable. %s", b.Name, b.InstallInstructions)
 		}
 	})
-	return b.fullPath
+	return b.fullPath, b.err
 }
 
 var (
@@ -42,20 +40,28 @@ var (
 	dockerBinLookup = binLookup{
 		Name: "docker",
 	}
+	dlvBinLookup = binLookup{
+		Name:                "dlv",
+		InstallInstructions: "Please install delve with `go install github.com/go-delve/delve/cmd/dlv@latest`.\nhttps://github.com/go-delve/delve",
+	}
 )
 
-func GoBin() string {
+func GoBin() (string, error) {
 	return goBinLookup.FullPath()
 }
 
-func FlutterBin() string {
+func FlutterBin() (string, error) {
 	return flutterBinLookup.FullPath()
 }
 
-func GitBin() string {
+func GitBin() (string, error) {
 	return gitBinLookup.FullPath()
 }
 
-func DockerBin() string {
+func DockerBin() (string, error) {
 	return dockerBinLookup.FullPath()
 }
+
+func DlvBin() (string, error) {
+	return dlvBinLookup.FullPath()
+}
@@ -1,11 +1,10 @@
 package build
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
 
-	"github.com/go-flutter-desktop/hover/internal/log"
+	"github.com/pkg/errors"
 )
 
 // BuildPath sets the name of the directory used to store the go-flutter project.
@@ -14,27 +13,25 @@ const BuildPath = "go"
 
 // buildDirectoryPath returns the path in `BuildPath`/build.
 // If needed, the directory is create at the returned path.
-func buildDirectoryPath(targetOS string, mode Mode, path string) string {
-	outputDirectoryPath, err := filepath.Abs(filepath.Join(BuildPath, "build", path, fmt.Sprintf("%s-%s", targetOS, mode.Name)))
+func buildDirectoryPath(targetOS, arch string, mode Mode, path string) (string, error) {
+	outputDirectoryPath, err := filepath.Abs(filepath.Join(BuildPath, "build", path, TargetName(targetOS, arch, mode)))
 	if err != nil {
-		log.Errorf("Failed to resolve absolute path for output directory: %v", err)
-		os.Exit(1)
+		return "", errors.Wrap(err, "failed to resolve absolute path for output directory")
 	}
 	if _, err := os.Stat(outputDirectoryPath); os.IsNotExist(err) {
 		err = os.MkdirAll(outputDirectoryPath, 0775)
 		if err != nil {
-			log.Errorf("Failed to create output directory %s: %v", outputDirectoryPath, err)
-			os.Exit(1)
+			return "", errors.Wrapf(err, "failed to create output directory %s", outputDirectoryPath)
 		}
 	}
-	return outputDirectoryPath
+	return outputDirectoryPath, nil
 }
 
 // OutputDirectoryPath returns the path where the go-flutter binary and flutter
 // binaries blobs will be stored for a particular platform.
 // If needed, the directory is create at the returned path.
-func OutputDirectoryPath(targetOS string, mode Mode) string {
-	return buildDirectoryPath(targetOS, mode, "outputs")
+func OutputDirectoryPath(targetOS, arch string, mode Mode) (string, error) {
+	return buildDirectoryPath(targetOS, arch, mode, "outputs")
 }
 
 // IntermediatesDirectoryPath returns the path where the intermediates stored.
@@ -43,49 +40,44 @@ func OutputDirectoryPath(targetOS string, mode Mode) string {
 // Those intermediates include the dynamic library dependencies of go-flutter plugins.
 // hover copies these intermediates from flutter plugins folder when `hover plugins get`, and
 // copies to go-flutter's binary output folder before build.
-func IntermediatesDirectoryPath(targetOS string, mode Mode) string {
-	return buildDirectoryPath(targetOS, mode, "intermediates")
+func IntermediatesDirectoryPath(targetOS, arch string, mode Mode) (string, error) {
+	return buildDirectoryPath(targetOS, arch, mode, "intermediates")
 }
 
 // OutputBinary returns the string of the executable used to launch the
 // main desktop app. (appends .exe for windows)
 func OutputBinary(executableName, targetOS string) string {
-	var outputBinaryName = executableName
-	switch targetOS {
-	case "darwin":
-		// no special filename
-	case "linux":
-		// no special filename
-	case "windows":
-		outputBinaryName += ".exe"
-	default:
-		log.Errorf("Target platform %s is not supported.", targetOS)
-		os.Exit(1)
-	}
-	return outputBinaryName
+	return executableName + ExecutableExtension(targetOS)
 }
 
 // OutputBinaryPath returns the path to the go-flutter Application for a
 // specified platform.
-func OutputBinaryPath(executableName, targetOS string, mode Mode) string {
-	outputBinaryPath := filepath.Join(OutputDirectoryPath(targetOS, mode), OutputBinary(executableName, targetOS))
-	return outputBinaryPath
+func OutputBinaryPath(executableName, targetOS, arch string, mode Mode) (string, error) {
+	outputDirectoryPath, err := OutputDirectoryPath(targetOS, arch, mode)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(outputDirectoryPath, OutputBinary(executableName, targetOS)), nil
+}
+
+// ValidateTargetOS returns an error when hover cannot build for targetOS.
+// The other helpers of this package expect a valid targetOS.
+func ValidateTargetOS(targetOS string) error {
+	switch targetOS {
+	case "darwin", "linux", "windows":
+		return nil
+	default:
+		return errors.Errorf("target platform %s is not supported", targetOS)
+	}
 }
 
 // ExecutableExtension returns the extension of binary files on a given platform
 func ExecutableExtension(targetOS string) string {
 	switch targetOS {
-	case "darwin":
-		// no special filename
-		return ""
-	case "linux":
-		// no special filename
-		return ""
 	case "windows":
 		return ".exe"
 	default:
-		log.Errorf("Target platform %s is not supported.", targetOS)
-		os.Exit(1)
+		// no special filename
 		return ""
 	}
 }
@@ -109,8 +101,6 @@ func EngineFiles(targetOS string, mode Mode) []string {
 			return []string{"flutter_engine.dll"}
 		}
 	default:
-		log.Errorf("%s has no implemented engine file", targetOS)
-		os.Exit(1)
 		return []string{}
 	}
 }
@@ -0,0 +1,106 @@
+package build
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ParseDartDefine splits a KEY=VALUE compile-time constant, as given to
+// --dart-define.
+func ParseDartDefine(define string) (string, string, error) {
+	i := strings.Index(define, "=")
+	if i <= 0 {
+		return "", "", errors.Errorf("invalid dart define %q, expected KEY=VALUE", define)
+	}
+	return define[:i], define[i+1:], nil
+}
+
+// ReadDartDefineFile reads the compile-time constants of a file given to
+// --dart-define-from-file. Like in the flutter tool, the file is either a
+// JSON object of strings, numbers and booleans, or, when it doesn't end in
+// .json, a .env file of KEY=VALUE lines.
+func ReadDartDefineFile(path string) (map[string]string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the dart defines file")
+	}
+	defines := make(map[string]string)
+	if filepath.Ext(path) == ".json" {
+		var values map[string]interface{}
+		err = json.Unmarshal(content, &values)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s", path)
+		}
+		for key, value := range values {
+			switch value.(type) {
+			case string, float64, bool:
+				defines[key] = fmt.Sprint(value)
+			default:
+				return nil, errors.Errorf("the value of %s in %s must be a string, a number or a boolean", key, path)
+			}
+		}
+		return defines, nil
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, err := ParseDartDefine(line)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s:%d", path, lineNumber)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		defines[key] = value
+	}
+	return defines, nil
+}
+
+// MergeDartDefines returns the KEY=VALUE compile-time constants of defaults,
+// of the files and of defines, sorted by key. A key of a file overrides the
+// defaults and the previous files, a key of defines overrides them all.
+func MergeDartDefines(defaults map[string]string, files []string, defines []string) ([]string, error) {
+	merged := make(map[string]string)
+	for key, value := range defaults {
+		merged[key] = value
+	}
+	for _, file := range files {
+		fileDefines, err := ReadDartDefineFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range fileDefines {
+			merged[key] = value
+		}
+	}
+	for _, define := range defines {
+		key, value, err := ParseDartDefine(define)
+		if err != nil {
+			return nil, err
+		}
+		merged[key] = value
+	}
+	keys := make([]string, 0, len(merged))
+	for key := range merged {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make([]string, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, key+"="+merged[key])
+	}
+	return result, nil
+}
@@ -0,0 +1,45 @@
+package build
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeDartDefines(t *testing.T) {
+	dir := t.TempDir()
+	jsonFile := filepath.Join(dir, "env.json")
+	err := ioutil.WriteFile(jsonFile, []byte(`{"API_URL": "https://staging", "RETRIES": 3, "BETA": true}`), 0644)
+	require.NoError(t, err)
+	envFile := filepath.Join(dir, "local.env")
+	err = ioutil.WriteFile(envFile, []byte("# local overrides\nAPI_URL=\"http://localhost:8080\"\n\nTOKEN = a=b\n"), 0644)
+	require.NoError(t, err)
+
+	defines, err := MergeDartDefines(
+		map[string]string{"API_URL": "https://prod", "FLAVOR": "prod"},
+		[]string{jsonFile, envFile},
+		[]string{"FLAVOR=dev", "EMPTY="},
+	)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"API_URL=http://localhost:8080",
+		"BETA=true",
+		"EMPTY=",
+		"FLAVOR=dev",
+		"RETRIES=3",
+		"TOKEN=a=b",
+	}, defines)
+}
+
+func TestMergeDartDefinesInvalid(t *testing.T) {
+	_, err := MergeDartDefines(nil, nil, []string{"=value"})
+	require.Error(t, err)
+
+	jsonFile := filepath.Join(t.TempDir(), "env.json")
+	err = ioutil.WriteFile(jsonFile, []byte(`{"NESTED": {"KEY": "value"}}`), 0644)
+	require.NoError(t, err)
+	_, err = MergeDartDefines(nil, []string{jsonFile}, nil)
+	require.Error(t, err)
+}
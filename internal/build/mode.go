@@ -1,5 +1,7 @@
 package build
 
+import "github.com/pkg/errors"
+
 type Mode struct {
 	Name  string
 	IsAot bool
@@ -25,3 +27,16 @@ var ProfileMode = Mode{
 	Name:  "profile",
 	IsAot: true,
 }
+
+// ParseMode returns the mode with the given name: debug, release or profile.
+func ParseMode(name string) (Mode, error) {
+	switch name {
+	case "debug", DebugMode.Name:
+		return DebugMode, nil
+	case ReleaseMode.Name:
+		return ReleaseMode, nil
+	case ProfileMode.Name:
+		return ProfileMode, nil
+	}
+	return Mode{}, errors.Errorf("unknown build mode %q, must be one of debug, release or profile", name)
+}
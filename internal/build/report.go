@@ -0,0 +1,135 @@
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Report describes the outcome of a build. It is written when hover build
+// is run with `--report json`, so that release tooling doesn't have to guess
+// the names of the artifacts.
+type Report struct {
+	HoverVersion     string         `json:"hoverVersion"`
+	GoFlutterVersion string         `json:"goFlutterVersion"`
+	Mode             string         `json:"mode"`
+	Arch             string         `json:"arch"`
+	Stages           []ReportStage  `json:"stages"`
+	Targets          []ReportTarget `json:"targets"`
+}
+
+// ReportStage is the timing of a single build stage. Target is the target OS
+// for the flutter and go stages and the build target for packaging stages.
+type ReportStage struct {
+	Name            string  `json:"name"`
+	Target          string  `json:"target"`
+	Skipped         bool    `json:"skipped"`
+	DurationSeconds float64 `json:"durationSeconds"`
+}
+
+// ReportTarget is the outcome of a single build target.
+type ReportTarget struct {
+	Name          string           `json:"name"`
+	TargetOS      string           `json:"targetOS"`
+	EngineVersion string           `json:"engineVersion"`
+	Success       bool             `json:"success"`
+	Error         string           `json:"error,omitempty"`
+	Artifacts     []ReportArtifact `json:"artifacts"`
+}
+
+// ReportArtifact is a file produced by the build.
+type ReportArtifact struct {
+	// Path is relative to the root of the flutter project.
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// AddStage records the duration of a build stage started at start.
+func (r *Report) AddStage(name, target string, skipped bool, start time.Time) {
+	r.Stages = append(r.Stages, ReportStage{
+		Name:            name,
+		Target:          target,
+		Skipped:         skipped,
+		DurationSeconds: time.Since(start).Seconds(),
+	})
+}
+
+// NewReportArtifacts returns the size and checksum of the file at path. When
+// path is a directory (eg: darwin .app bundles), every file it contains is
+// returned.
+func NewReportArtifacts(path string) ([]ReportArtifact, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get working dir")
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve absolute path of %s", path)
+	}
+	var artifacts []ReportArtifact
+	err = filepath.Walk(absPath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		artifact, err := newReportArtifact(wd, p)
+		if err != nil {
+			return err
+		}
+		artifacts = append(artifacts, artifact)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to checksum artifact %s", path)
+	}
+	return artifacts, nil
+}
+
+func newReportArtifact(wd, path string) (ReportArtifact, error) {
+	relPath, err := filepath.Rel(wd, path)
+	if err != nil {
+		return ReportArtifact{}, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return ReportArtifact{}, err
+	}
+	defer file.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, file)
+	if err != nil {
+		return ReportArtifact{}, err
+	}
+	return ReportArtifact{
+		Path:   filepath.ToSlash(relPath),
+		Size:   size,
+		SHA256: hex.EncodeToString(h.Sum(nil)),
+	}, nil
+}
+
+// WriteJSON writes the report as JSON to path.
+func (r *Report) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode build report")
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0775)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create directory for build report %s", path)
+	}
+	err = ioutil.WriteFile(path, append(data, '\n'), 0664)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write build report %s", path)
+	}
+	return nil
+}
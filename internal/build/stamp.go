@@ -0,0 +1,113 @@
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// StampsDirectoryPath returns the path where the hashes of the inputs of the
+// build stages are stored. Those stamps are used to skip a build stage when
+// its inputs didn't change since the last build.
+// If needed, the directory is create at the returned path.
+func StampsDirectoryPath(targetOS, arch string, mode Mode) (string, error) {
+	return buildDirectoryPath(targetOS, arch, mode, "stamps")
+}
+
+// HashInputs returns a hash of the content of the given files and
+// directories, and of the given values. Missing paths are part of the hash,
+// so that creating them invalidates it.
+func HashInputs(paths []string, values ...string) (string, error) {
+	h := sha256.New()
+	for _, value := range values {
+		io.WriteString(h, "value:"+value+"\n")
+	}
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			io.WriteString(h, "missing:"+filepath.ToSlash(path)+"\n")
+			continue
+		}
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to stat %s", path)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.Mode().IsRegular() {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to list files in %s", path)
+		}
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		io.WriteString(h, "file:"+filepath.ToSlash(file)+"\n")
+		f, err := os.Open(file)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to open %s", file)
+		}
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to read %s", file)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// StampUpToDate returns true when the stamp of a build stage matches hash.
+func StampUpToDate(targetOS, arch string, mode Mode, stage, hash string) bool {
+	stampsDirectoryPath, err := StampsDirectoryPath(targetOS, arch, mode)
+	if err != nil {
+		return false
+	}
+	stamp, err := ioutil.ReadFile(filepath.Join(stampsDirectoryPath, stage))
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(stamp)) == hash
+}
+
+// WriteStamp records hash as the inputs of the last successful build of a
+// build stage.
+func WriteStamp(targetOS, arch string, mode Mode, stage, hash string) error {
+	stampsDirectoryPath, err := StampsDirectoryPath(targetOS, arch, mode)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(filepath.Join(stampsDirectoryPath, stage), []byte(hash+"\n"), 0664)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write the %s build stamp", stage)
+	}
+	return nil
+}
+
+// RemoveStamp invalidates the stamp of a build stage.
+func RemoveStamp(targetOS, arch string, mode Mode, stage string) error {
+	stampsDirectoryPath, err := StampsDirectoryPath(targetOS, arch, mode)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(filepath.Join(stampsDirectoryPath, stage))
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to remove the %s build stamp", stage)
+	}
+	return nil
+}
@@ -34,7 +34,45 @@ type Config struct {
 	BranchREMOVED    string `yaml:"branch"`
 	CachePathREMOVED string `yaml:"cache-path"`
 	OpenGL           string
-	Engine           string `yaml:"engine-version"`
+	Engine           string            `yaml:"engine-version"`
+	EngineMirror     string            `yaml:"engine-mirror"`
+	DartDefines      map[string]string `yaml:"dart-defines"`
+	GoBuild          GoBuildConfig     `yaml:"go-build"`
+	Hooks            HooksConfig
+}
+
+// HooksConfig contains the hooks section of hover.yaml, shell commands run
+// before and after the build stages.
+type HooksConfig struct {
+	PreFlutter  string `yaml:"pre-flutter"`
+	PostFlutter string `yaml:"post-flutter"`
+	PreGo       string `yaml:"pre-go"`
+	PostGo      string `yaml:"post-go"`
+	PrePackage  string `yaml:"pre-package"`
+	PostPackage string `yaml:"post-package"`
+}
+
+// GoBuildConfig contains the go-build section of hover.yaml, the additions to
+// the `go build` command of the app.
+type GoBuildConfig struct {
+	// Tags are added to the build tags.
+	Tags []string
+	// Variables are string variables set with -X, by their import path
+	// qualified name, e.g. main.commit. Environment variables in the values
+	// are expanded.
+	Variables map[string]string
+	// Trimpath removes the file system paths from the binary.
+	Trimpath bool
+	// Race enables the race detector in debug builds.
+	Race bool
+	// Cgo contains extra CGO flags, by target OS.
+	Cgo map[string]CgoFlags
+}
+
+// CgoFlags are appended to the CGO_CFLAGS and CGO_LDFLAGS of a build.
+type CgoFlags struct {
+	Cflags  string
+	Ldflags string
 }
 
 func (c Config) GetApplicationName(projectName string) string {
@@ -78,36 +116,53 @@ func (c Config) GetLicense() string {
 
 var (
 	config         Config
+	configErr      error
 	configLoadOnce sync.Once
 )
 
-// GetConfig returns the working directory hover.yaml as a Config
-func GetConfig() Config {
+// LoadConfig reads the working directory hover.yaml. The result is cached,
+// following calls to LoadConfig and GetConfig return the same Config.
+// A missing hover.yaml isn't an error, an empty Config is returned.
+func LoadConfig() (Config, error) {
 	configLoadOnce.Do(func() {
-		var err error
-		hoverYaml := GetHoverFlavorYaml()
-		config, err = ReadConfigFile(filepath.Join(build.BuildPath, hoverYaml))
-		if err != nil {
-			if os.IsNotExist(errors.Cause(err)) {
-				// TODO: Add a solution for the user. Perhaps we can let `hover
-				// init` write missing files when ran on an existing project.
-				// https://github.com/go-flutter-desktop/hover/pull/121#pullrequestreview-408680348
-				log.Warnf("Missing config: %v", err)
-				return
-			}
-			log.Errorf("Failed to load config: %v", err)
-			os.Exit(1)
-		}
+		config, configErr = loadConfig()
+	})
+	return config, configErr
+}
 
-		if config.CachePathREMOVED != "" {
-			log.Errorf("The hover.yaml field 'cache-path' is not used anymore. Remove it from your hover.yaml and use --cache-path instead.")
-			os.Exit(1)
+func loadConfig() (Config, error) {
+	hoverYaml := GetHoverFlavorYaml()
+	config, err := ReadConfigFile(filepath.Join(build.BuildPath, hoverYaml))
+	if err != nil {
+		if os.IsNotExist(errors.Cause(err)) {
+			// TODO: Add a solution for the user. Perhaps we can let `hover
+			// init` write missing files when ran on an existing project.
+			// https://github.com/go-flutter-desktop/hover/pull/121#pullrequestreview-408680348
+			log.Warnf("Missing config: %v", err)
+			return Config{}, nil
 		}
-		if config.BranchREMOVED != "" {
-			log.Errorf("The hover.yaml field 'branch' is not used anymore. Remove it from your hover.yaml and use --branch instead.")
-			os.Exit(1)
+		return Config{}, errors.Wrap(err, "failed to load config")
+	}
+
+	if config.CachePathREMOVED != "" {
+		return Config{}, errors.New("the hover.yaml field 'cache-path' is not used anymore. Remove it from your hover.yaml and use --cache-path instead")
+	}
+	if config.BranchREMOVED != "" {
+		return Config{}, errors.New("the hover.yaml field 'branch' is not used anymore. Remove it from your hover.yaml and use --branch instead")
+	}
+	for targetOS := range config.GoBuild.Cgo {
+		if err := build.ValidateTargetOS(targetOS); err != nil {
+			return Config{}, errors.Wrapf(err, "invalid go-build cgo section in %s", hoverYaml)
 		}
-	})
+	}
+	return config, nil
+}
+
+// GetConfig returns the working directory hover.yaml as a Config.
+// Errors are only returned by LoadConfig, which should be called first. When
+// hover.yaml failed to load, GetConfig returns an empty Config.
+func GetConfig() Config {
+	config, _ := LoadConfig()
 	return config
 }
 
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
 
 	"github.com/go-flutter-desktop/hover/internal/build"
-	"github.com/go-flutter-desktop/hover/internal/log"
 )
 
 var hoverYaml string
@@ -21,20 +23,29 @@ func GetHoverFlavorYaml() string {
 
 // SetHoverFlavor sets the user defined hover flavor.
 // eg. hover-develop.yaml, hover-staging.yaml, etc.
-func SetHoverFlavor(flavor string) {
-	hoverYaml = fmt.Sprintf("hover-%s.yaml", flavor)
-	assertYamlFileExists(hoverYaml)
+// The config is loaded again from the flavor yaml file by the next call to
+// LoadConfig or GetConfig.
+func SetHoverFlavor(flavor string) error {
+	yamlFile := fmt.Sprintf("hover-%s.yaml", flavor)
+	err := assertYamlFileExists(yamlFile)
+	if err != nil {
+		return err
+	}
+	if hoverYaml != yamlFile {
+		hoverYaml = yamlFile
+		configLoadOnce = sync.Once{}
+	}
+	return nil
 }
 
 // assertYamlFileExists checks to see if the user defined yaml file exists
-func assertYamlFileExists(yamlFile string) {
+func assertYamlFileExists(yamlFile string) error {
 	_, err := os.Stat(filepath.Join(build.BuildPath, yamlFile))
 	if os.IsNotExist(err) {
-		log.Warnf("Hover Yaml file \"%s\" not found.", yamlFile)
-		os.Exit(1)
+		return errors.Errorf("hover yaml file \"%s\" not found", yamlFile)
 	}
 	if err != nil {
-		log.Errorf("Failed to stat %s: %v\n", yamlFile, err)
-		os.Exit(1)
+		return errors.Wrapf(err, "failed to stat %s", yamlFile)
 	}
+	return nil
 }
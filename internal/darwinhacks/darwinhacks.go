@@ -1,6 +1,7 @@
 package darwinhacks
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -9,13 +10,13 @@ import (
 	"runtime"
 	"strings"
 
-	"github.com/go-flutter-desktop/hover/internal/log"
+	"github.com/pkg/errors"
 )
 
 // DyldHack is a nasty hack to get the linking working. After fiddling a lot of hours with CGO linking
 // this was the only solution I could come up with and it works. I guess something would need to be changed in the engine
 // builds to make this obsolete, but this hack does it for now.
-func DyldHack(path string) {
+func DyldHack(ctx context.Context, path string) error {
 	installNameToolCommand := []string{
 		"install_name_tool",
 		"-change",
@@ -28,17 +29,16 @@ func DyldHack(path string) {
 	if runtime.GOOS != "darwin" {
 		installNameToolCommand = append([]string{"darling", "shell"}, installNameToolCommand...)
 	}
-	cmdInstallNameTool := exec.Command(
+	cmdInstallNameTool := exec.CommandContext(ctx,
 		installNameToolCommand[0],
 		installNameToolCommand[1:]...,
 	)
 	cmdInstallNameTool.Stderr = os.Stderr
 	output, err := cmdInstallNameTool.Output()
 	if err != nil {
-		log.Errorf("install_name_tool failed: %v", err)
-		log.Errorf(string(output))
-		os.Exit(1)
+		return errors.Wrapf(err, "install_name_tool failed: %s", output)
 	}
+	return nil
 }
 
 func RewriteDarlingPath(useDarling bool, path string) string {
@@ -48,12 +48,11 @@ func RewriteDarlingPath(useDarling bool, path string) string {
 	return path
 }
 
-func ChangePackagesFilePath(isInsert bool) {
+func ChangePackagesFilePath(isInsert bool) error {
 	for _, path := range []string{".packages", filepath.Join(".dart_tool", "package_config.json")} {
 		content, err := ioutil.ReadFile(path)
 		if err != nil {
-			log.Errorf("Failed to read %s file: %v", path, err)
-			os.Exit(1)
+			return errors.Wrapf(err, "failed to read %s file", path)
 		}
 		lines := strings.Split(string(content), "\n")
 		for i := range lines {
@@ -68,8 +67,8 @@ func ChangePackagesFilePath(isInsert bool) {
 		}
 		err = ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644)
 		if err != nil {
-			log.Errorf("Failed to write %s file: %v", path, err)
-			os.Exit(1)
+			return errors.Wrapf(err, "failed to write %s file", path)
 		}
 	}
+	return nil
 }
@@ -0,0 +1,267 @@
+package enginecache
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/go-flutter-desktop/hover/internal/build"
+	"github.com/go-flutter-desktop/hover/internal/log"
+	"github.com/go-flutter-desktop/hover/internal/version"
+)
+
+// An engine archive is a gzipped tar file. Its first entry is the manifest,
+// followed by the files of the engine cache directory under engineArchiveDir.
+const (
+	engineArchiveManifestName = "hover-engine.json"
+	engineArchiveDir          = "engine"
+)
+
+// ArchiveManifest describes the engine contained in an engine archive.
+type ArchiveManifest struct {
+	OS   string `json:"os"`
+	Arch string `json:"arch"`
+	Mode string `json:"mode"`
+	// Version is the content of the version file of the engine cache.
+	Version string `json:"version"`
+}
+
+// ExportEngine writes the cached engine of targetOS, arch and mode at
+// engineVersion to w as an engine archive. The engine is locked while it is
+// read, so that it isn't replaced or removed halfway through.
+func ExportEngine(ctx context.Context, w io.Writer, targetOS, arch, cachePath, engineVersion string, mode build.Mode) error {
+	engineCachePath := EngineCachePath(targetOS, arch, cachePath, engineVersion, mode)
+	lock, err := LockEngine(ctx, cachePath, engineCachePath)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	cachedVersion, err := ioutil.ReadFile(filepath.Join(engineCachePath, "version"))
+	if os.IsNotExist(err) {
+		return errors.Errorf("the %s engine at version %s is not in the cache at %s, run `hover prepare-engine` first", EngineConfig(targetOS, arch, mode), engineVersion, engineCachePath)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to read cached engine version")
+	}
+
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	manifest, err := json.MarshalIndent(ArchiveManifest{
+		OS:      targetOS,
+		Arch:    arch,
+		Mode:    mode.Name,
+		Version: string(cachedVersion),
+	}, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode engine archive manifest")
+	}
+	err = tarWriter.WriteHeader(&tar.Header{
+		Name:     engineArchiveManifestName,
+		Typeflag: tar.TypeReg,
+		Mode:     0644,
+		Size:     int64(len(manifest)),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to write engine archive")
+	}
+	_, err = tarWriter.Write(manifest)
+	if err != nil {
+		return errors.Wrap(err, "failed to write engine archive")
+	}
+
+	err = filepath.Walk(engineCachePath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(engineCachePath, filePath)
+		if err != nil || relPath == "." {
+			return err
+		}
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err = os.Readlink(filePath)
+			if err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(engineArchiveDir, filepath.ToSlash(relPath))
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		header.Uid, header.Gid, header.Uname, header.Gname = 0, 0, "", ""
+		err = tarWriter.WriteHeader(header)
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		file, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tarWriter, file)
+		return err
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to write engine archive")
+	}
+	err = tarWriter.Close()
+	if err != nil {
+		return errors.Wrap(err, "failed to write engine archive")
+	}
+	return gzipWriter.Close()
+}
+
+// ImportEngine validates the engine archive read from r and installs it in the
+// engine cache, replacing the cached engine of the same platform and mode.
+func ImportEngine(ctx context.Context, r io.Reader, cachePath string) (ArchiveManifest, error) {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return ArchiveManifest{}, errors.Wrap(err, "not an engine archive")
+	}
+	defer gzipReader.Close()
+	tarReader := tar.NewReader(gzipReader)
+
+	header, err := tarReader.Next()
+	if err != nil || header.Name != engineArchiveManifestName {
+		return ArchiveManifest{}, errors.Errorf("not an engine archive, %s is missing", engineArchiveManifestName)
+	}
+	var manifest ArchiveManifest
+	err = json.NewDecoder(tarReader).Decode(&manifest)
+	if err != nil {
+		return ArchiveManifest{}, errors.Wrapf(err, "failed to decode %s", engineArchiveManifestName)
+	}
+	if err := build.ValidateTargetOS(manifest.OS); err != nil {
+		return manifest, errors.Wrap(err, "invalid engine archive")
+	}
+	if err := build.ValidateArch(manifest.Arch); err != nil {
+		return manifest, errors.Wrap(err, "invalid engine archive")
+	}
+	mode, err := build.ParseMode(manifest.Mode)
+	if err != nil {
+		return manifest, errors.Wrap(err, "invalid engine archive")
+	}
+	engineVersion, _ := splitVersionStamp(manifest.Version)
+	if engineVersion == "" || strings.ContainsAny(engineVersion, `/\.`) {
+		return manifest, errors.Errorf("invalid engine archive, bad engine version %q", manifest.Version)
+	}
+
+	engineCachePath := EngineCachePath(manifest.OS, manifest.Arch, cachePath, engineVersion, mode)
+	lock, err := LockEngine(ctx, cachePath, engineCachePath)
+	if err != nil {
+		return manifest, err
+	}
+	defer lock.Release()
+	err = os.MkdirAll(filepath.Dir(engineCachePath), 0775)
+	if err != nil {
+		return manifest, errors.Wrap(err, "failed to create engine cache directory")
+	}
+	stagingPath, err := createEngineStagingDir(engineCachePath)
+	if err != nil {
+		return manifest, err
+	}
+	defer os.RemoveAll(stagingPath)
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return manifest, errors.Wrap(err, "failed to read engine archive")
+		}
+		err = extractArchiveEntry(tarReader, header, stagingPath)
+		if err != nil {
+			return manifest, err
+		}
+	}
+
+	extractedVersion, err := ioutil.ReadFile(filepath.Join(stagingPath, "version"))
+	if err != nil || string(extractedVersion) != manifest.Version {
+		return manifest, errors.New("invalid engine archive, the version file doesn't match the manifest")
+	}
+	requiredFiles := append([]string{"icudtl.dat"}, build.EngineFiles(manifest.OS, mode)...)
+	for _, file := range requiredFiles {
+		_, err := os.Lstat(filepath.Join(stagingPath, file))
+		if err != nil {
+			return manifest, errors.Errorf("invalid engine archive, %s is missing", file)
+		}
+	}
+	if manifest.Version != versionStamp(engineVersion) {
+		// the engine files only depend on the engine version, the engine is
+		// used by this hover instead of being downloaded again
+		_, hoverVersion := splitVersionStamp(manifest.Version)
+		log.Infof("The engine was exported by hover %s, it's imported for hover %s", hoverVersion, version.HoverVersion())
+		err = ioutil.WriteFile(filepath.Join(stagingPath, "version"), []byte(versionStamp(engineVersion)), 0664)
+		if err != nil {
+			return manifest, errors.Wrap(err, "failed to write the imported engine version")
+		}
+	}
+
+	err = os.RemoveAll(engineCachePath)
+	if err != nil {
+		return manifest, errors.Wrap(err, "failed to remove cached engine")
+	}
+	err = os.Rename(stagingPath, engineCachePath)
+	if err != nil {
+		return manifest, errors.Wrap(err, "failed to install imported engine")
+	}
+	return manifest, nil
+}
+
+// extractArchiveEntry writes the engine archive entry described by header to
+// stagingPath. Entries outside of the engine directory, or pointing outside
+// of it, are rejected.
+func extractArchiveEntry(r io.Reader, header *tar.Header, stagingPath string) error {
+	relPath := strings.TrimPrefix(header.Name, engineArchiveDir+"/")
+	if relPath == header.Name || path.IsAbs(relPath) || path.Clean(relPath) != strings.TrimSuffix(relPath, "/") ||
+		strings.HasPrefix(path.Clean(relPath), "..") {
+		return errors.Errorf("invalid engine archive, illegal file path %s", header.Name)
+	}
+	target := filepath.Join(stagingPath, filepath.FromSlash(relPath))
+
+	switch header.Typeflag {
+	case tar.TypeDir:
+		return os.MkdirAll(target, 0775)
+	case tar.TypeSymlink:
+		linkTarget := path.Join(path.Dir(path.Clean(relPath)), header.Linkname)
+		if path.IsAbs(header.Linkname) || strings.HasPrefix(linkTarget, "..") {
+			return errors.Errorf("invalid engine archive, illegal link %s -> %s", header.Name, header.Linkname)
+		}
+		err := os.MkdirAll(filepath.Dir(target), 0775)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(header.Linkname, target)
+	case tar.TypeReg:
+		err := os.MkdirAll(filepath.Dir(target), 0775)
+		if err != nil {
+			return err
+		}
+		file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, header.FileInfo().Mode().Perm())
+		if err != nil {
+			return errors.Wrapf(err, "failed to extract %s", header.Name)
+		}
+		defer file.Close()
+		_, err = io.Copy(file, r)
+		if err != nil {
+			return errors.Wrapf(err, "failed to extract %s", header.Name)
+		}
+		return file.Close()
+	}
+	return errors.Errorf("invalid engine archive, unsupported file type of %s", header.Name)
+}
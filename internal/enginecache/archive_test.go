@@ -0,0 +1,82 @@
+package enginecache
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-flutter-desktop/hover/internal/build"
+	"github.com/go-flutter-desktop/hover/internal/version"
+)
+
+func TestExportImportEngine(t *testing.T) {
+	sourceCache := t.TempDir()
+	engineCachePath := EngineCachePath("linux", "amd64", sourceCache, "abc123", build.DebugMode)
+	require.NoError(t, os.MkdirAll(filepath.Join(engineCachePath, "gen"), 0775))
+	for _, file := range append([]string{"icudtl.dat", "gen/snapshot"}, build.EngineFiles("linux", build.DebugMode)...) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(engineCachePath, file), []byte(file), 0664))
+	}
+	require.NoError(t, ioutil.WriteFile(filepath.Join(engineCachePath, "version"), []byte("abc123-v0.1.0"), 0664))
+
+	var archive bytes.Buffer
+	require.NoError(t, ExportEngine(context.Background(), &archive, "linux", "amd64", sourceCache, "abc123", build.DebugMode))
+
+	targetCache := t.TempDir()
+	manifest, err := ImportEngine(context.Background(), bytes.NewReader(archive.Bytes()), targetCache)
+	require.NoError(t, err)
+	require.Equal(t, ArchiveManifest{OS: "linux", Arch: "amd64", Mode: build.DebugMode.Name, Version: "abc123-v0.1.0"}, manifest)
+	snapshot, err := ioutil.ReadFile(filepath.Join(EngineCachePath("linux", "amd64", targetCache, "abc123", build.DebugMode), "gen", "snapshot"))
+	require.NoError(t, err)
+	require.Equal(t, "gen/snapshot", string(snapshot))
+
+	entries, err := ListCache(targetCache)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "linux-debug_unopt", entries[0].Name)
+	require.Equal(t, "abc123", entries[0].EngineVersion)
+	// the engine is stamped with the version of the hover importing it
+	require.Equal(t, version.HoverVersion(), entries[0].HoverVersion)
+}
+
+func TestImportEngineOfAnotherHoverVersionIsValid(t *testing.T) {
+	sourceCache := t.TempDir()
+	engineCachePath := EngineCachePath("linux", "amd64", sourceCache, "abc123", build.ReleaseMode)
+	require.NoError(t, os.MkdirAll(engineCachePath, 0775))
+	for _, file := range append([]string{"icudtl.dat"}, build.EngineFiles("linux", build.ReleaseMode)...) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(engineCachePath, file), []byte(file), 0664))
+	}
+	require.NoError(t, ioutil.WriteFile(filepath.Join(engineCachePath, "version"), []byte("abc123-v0.0.1"), 0664))
+	var archive bytes.Buffer
+	require.NoError(t, ExportEngine(context.Background(), &archive, "linux", "amd64", sourceCache, "abc123", build.ReleaseMode))
+
+	targetCache := t.TempDir()
+	_, err := ImportEngine(context.Background(), &archive, targetCache)
+	require.NoError(t, err)
+	// a download from this mirror fails, the imported engine must be used
+	err = ValidateOrUpdateEngine(context.Background(), "linux", "amd64", targetCache, "abc123", "http://127.0.0.1:1", build.ReleaseMode)
+	require.NoError(t, err)
+}
+
+func TestImportEngineRejectsPathTraversal(t *testing.T) {
+	var archive bytes.Buffer
+	gzipWriter := gzip.NewWriter(&archive)
+	tarWriter := tar.NewWriter(gzipWriter)
+	manifest := []byte(`{"os": "linux", "arch": "amd64", "mode": "debug_unopt", "version": "abc"}`)
+	require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: engineArchiveManifestName, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(manifest))}))
+	_, err := tarWriter.Write(manifest)
+	require.NoError(t, err)
+	require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "engine/../../evil", Typeflag: tar.TypeReg, Mode: 0644}))
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, gzipWriter.Close())
+
+	_, err = ImportEngine(context.Background(), &archive, t.TempDir())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "illegal file path")
+}
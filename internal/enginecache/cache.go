@@ -2,23 +2,22 @@ package enginecache
 
 import (
 	"archive/zip"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
-	"strconv"
 	"strings"
-	"time"
 
 	"github.com/otiai10/copy"
 	"github.com/pkg/errors"
 
 	"github.com/go-flutter-desktop/hover/internal/build"
 	"github.com/go-flutter-desktop/hover/internal/darwinhacks"
+	"github.com/go-flutter-desktop/hover/internal/filelock"
 	"github.com/go-flutter-desktop/hover/internal/log"
 	"github.com/go-flutter-desktop/hover/internal/version"
 )
@@ -93,143 +92,135 @@ func unzip(src string, dest string) ([]string, error) {
 	return filenames, nil
 }
 
-// Function to prind download percent completion
-func printDownloadPercent(done chan chan struct{}, path string, expectedSize int64) {
-	var completedCh chan struct{}
-	for {
-		fi, err := os.Stat(path)
-		if err != nil {
-			log.Warnf("%v", err)
-		}
-
-		size := fi.Size()
-
-		if size == 0 {
-			size = 1
-		}
-
-		var percent = float64(size) / float64(expectedSize) * 100
-
-		// We use '\033[2K\r' to avoid carriage return, it will print above previous.
-		fmt.Printf("\033[2K\r %.0f %% / 100 %%", percent)
-
-		if completedCh != nil {
-			close(completedCh)
-			return
-		}
+func EngineConfig(targetOS, arch string, mode build.Mode) string {
+	return build.TargetName(targetOS, arch, mode)
+}
 
-		select {
-		case completedCh = <-done:
-		case <-time.After(time.Second / 60): // Flutter promises 60fps, right? ;)
-		}
-	}
+// EngineCachePath returns the directory of the engine for targetOS, arch and
+// mode at engineVersion. Engines are cached per engine version, so that
+// projects using different flutter versions don't evict each other's engine.
+//
+//noinspection GoNameStartsWithPackageName
+func EngineCachePath(targetOS, arch, cachePath, engineVersion string, mode build.Mode) string {
+	return filepath.Join(BaseEngineCachePath(cachePath), engineVersion, EngineConfig(targetOS, arch, mode))
 }
 
-// Function to download file with given path and url.
-func downloadFile(filepath string, url string) error {
-	// // Printf download url in case user needs it.
-	// log.Printf("Downloading file from\n '%s'\n to '%s'", url, filepath)
+func BaseEngineCachePath(cachePath string) string {
+	return filepath.Join(cachePath, "hover", "engine")
+}
 
-	start := time.Now()
+// locksPath returns the directory holding the lock files of the engines. The
+// lock files are kept apart from the engines, so that the directory of an
+// engine version can be removed without removing the lock files.
+func locksPath(cachePath string) string {
+	return filepath.Join(BaseEngineCachePath(cachePath), "locks")
+}
 
-	// Create the file
-	out, err := os.Create(filepath)
+// LockEngine takes the lock of the engine in engineCachePath, which must be
+// held while the engine is installed, removed or read.
+func LockEngine(ctx context.Context, cachePath, engineCachePath string) (*filelock.Lock, error) {
+	err := os.MkdirAll(locksPath(cachePath), 0775)
 	if err != nil {
-		return err
+		return nil, errors.Wrap(err, "failed to create engine cache directory")
 	}
-	defer out.Close()
-
-	// Get the data
-	resp, err := http.Get(url)
+	relPath, err := filepath.Rel(BaseEngineCachePath(cachePath), engineCachePath)
 	if err != nil {
-		return err
+		return nil, errors.Wrap(err, "failed to resolve engine lock")
 	}
-	defer resp.Body.Close()
+	lockPath := filepath.Join(locksPath(cachePath), strings.ReplaceAll(relPath, string(filepath.Separator), "-")+".lock")
+	return filelock.Acquire(ctx, lockPath, func() {
+		log.Infof("Waiting for another hover process to release the engine at %s", engineCachePath)
+	})
+}
 
-	expectedSize, err := strconv.Atoi(resp.Header.Get("Content-Length"))
+// createEngineStagingDir creates the directory in which the engine of
+// engineCachePath is installed, before it is renamed to engineCachePath once
+// complete, so that builds never see a partially installed engine. The staging
+// directories left behind by interrupted installs are removed. The lock of
+// the engine must be held.
+func createEngineStagingDir(engineCachePath string) (string, error) {
+	stagingPrefix := filepath.Join(filepath.Dir(engineCachePath), "."+filepath.Base(engineCachePath)+"-")
+	staleStagingPaths, err := filepath.Glob(stagingPrefix + "*")
 	if err != nil {
-		return errors.Wrap(err, "failed to get Content-Length header")
+		return "", errors.Wrap(err, "failed to list engine staging directories")
 	}
-
-	doneCh := make(chan chan struct{})
-	go printDownloadPercent(doneCh, filepath, int64(expectedSize))
-
-	_, err = io.Copy(out, resp.Body)
+	for _, staleStagingPath := range staleStagingPaths {
+		err = os.RemoveAll(staleStagingPath)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to remove engine staging directory")
+		}
+	}
+	stagingPath, err := ioutil.TempDir(filepath.Dir(engineCachePath), filepath.Base(stagingPrefix))
 	if err != nil {
-		return err
+		return "", errors.Wrap(err, "failed to create engine staging directory")
 	}
-
-	// close channel to indicate we're done
-	doneCompletedCh := make(chan struct{})
-	doneCh <- doneCompletedCh // signal that download is done
-	<-doneCompletedCh         // wait for signal that printing has completed
-
-	elapsed := time.Since(start)
-	log.Printf("\033[2K\rDownload completed in %.2fs", elapsed.Seconds())
-	return nil
-}
-
-func EngineConfig(targetOS string, mode build.Mode) string {
-	return fmt.Sprintf("%s-%s", targetOS, mode.Name)
-}
-
-//noinspection GoNameStartsWithPackageName
-func EngineCachePath(targetOS, cachePath string, mode build.Mode) string {
-	return filepath.Join(BaseEngineCachePath(cachePath), EngineConfig(targetOS, mode))
+	err = os.Chmod(stagingPath, 0775)
+	if err != nil {
+		os.RemoveAll(stagingPath)
+		return "", errors.Wrap(err, "failed to create engine staging directory")
+	}
+	return stagingPath, nil
 }
 
-func BaseEngineCachePath(cachePath string) string {
-	return filepath.Join(cachePath, "hover", "engine")
+// ResolveEngineVersion returns engineVersion, or the engine version required
+// by the installed flutter SDK when engineVersion is empty.
+func ResolveEngineVersion(ctx context.Context, engineVersion string) (string, error) {
+	if engineVersion != "" {
+		return engineVersion, nil
+	}
+	return version.FlutterRequiredEngineVersion(ctx)
 }
 
 // ValidateOrUpdateEngine validates the engine we have cached matches the
 // flutter version, or otherwise downloads a new engine. The engine cache
-// location is set by the the user.
-func ValidateOrUpdateEngine(targetOS, cachePath, requiredEngineVersion string, mode build.Mode) {
-	engineCachePath := EngineCachePath(targetOS, cachePath, mode)
+// location is set by the the user. The engine is downloaded from mirror when it
+// isn't empty, see artifactURL.
+func ValidateOrUpdateEngine(ctx context.Context, targetOS, arch, cachePath, requiredEngineVersion, mirror string, mode build.Mode) error {
+	requiredEngineVersion, err := ResolveEngineVersion(ctx, requiredEngineVersion)
+	if err != nil {
+		return err
+	}
+	engineCachePath := EngineCachePath(targetOS, arch, cachePath, requiredEngineVersion, mode)
 
 	if strings.Contains(engineCachePath, " ") {
-		log.Errorf("Cannot save the engine to '%s', engine cache is not compatible with path containing spaces.", cachePath)
 		log.Errorf("       Please run hover with a another engine cache path. Example:")
 		log.Errorf("              %s", log.Au().Magenta("hover run --cache-path \"C:\\cache\""))
 		log.Errorf("       The --cache-path flag will have to be provided to every build and run command.")
-		os.Exit(1)
+		return errors.Errorf("cannot save the engine to '%s', engine cache is not compatible with path containing spaces", cachePath)
 	}
 
-	cachedEngineVersionPath := filepath.Join(engineCachePath, "version")
-	cachedEngineVersionBytes, err := ioutil.ReadFile(cachedEngineVersionPath)
-	if err != nil && !os.IsNotExist(err) {
-		log.Errorf("Failed to read cached engine version: %v", err)
-		os.Exit(1)
-	}
-	cachedEngineVersion := string(cachedEngineVersionBytes)
-	if len(requiredEngineVersion) == 0 {
-		requiredEngineVersion = version.FlutterRequiredEngineVersion()
+	lock, err := LockEngine(ctx, cachePath, engineCachePath)
+	if err != nil {
+		return err
 	}
+	defer lock.Release()
 
-	if cachedEngineVersion == fmt.Sprintf("%s-%s", requiredEngineVersion, version.HoverVersion()) {
+	cachedEngineVersionBytes, err := ioutil.ReadFile(filepath.Join(engineCachePath, "version"))
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to read cached engine version")
+	}
+	if string(cachedEngineVersionBytes) == versionStamp(requiredEngineVersion) {
 		log.Printf("Using engine from cache")
-		return
-	} else {
-		// Engine is outdated, we remove the old engine and continue to download
-		// the new engine.
-		err = os.RemoveAll(engineCachePath)
+		err = markEngineUsed(engineCachePath)
 		if err != nil {
-			log.Errorf("Failed to remove outdated engine: %v", err)
-			os.Exit(1)
+			log.Warnf("%v", err)
 		}
+		return nil
 	}
 
-	err = os.MkdirAll(engineCachePath, 0775)
+	err = os.MkdirAll(filepath.Dir(engineCachePath), 0775)
 	if err != nil {
-		log.Errorf("Failed to create engine cache directory: %v", err)
-		os.Exit(1)
+		return errors.Wrap(err, "failed to create engine cache directory")
 	}
+	stagingPath, err := createEngineStagingDir(engineCachePath)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stagingPath)
 
 	dir, err := ioutil.TempDir("", "hover-engine-download")
 	if err != nil {
-		log.Errorf("Failed to create tmp dir for engine download: %v", err)
-		os.Exit(1)
+		return errors.Wrap(err, "failed to create tmp dir for engine download")
 	}
 	defer os.RemoveAll(dir)
 
@@ -238,48 +229,47 @@ func ValidateOrUpdateEngine(targetOS, cachePath, requiredEngineVersion string, m
 		log.Warnf("%v", err)
 	}
 
-	engineZipPath := filepath.Join(dir, "engine.zip")
 	engineExtractPath := filepath.Join(dir, "engine")
 
-	log.Printf("Downloading engine for platform %s at version %s...", EngineConfig(targetOS, mode), requiredEngineVersion)
+	log.Printf("Downloading engine for platform %s at version %s...", EngineConfig(targetOS, arch, mode), requiredEngineVersion)
 
 	if mode == build.DebugMode {
-		targetedDomain := "https://storage.googleapis.com"
-		envURLFlutter := os.Getenv("FLUTTER_STORAGE_BASE_URL")
-		if envURLFlutter != "" {
-			targetedDomain = envURLFlutter
-		}
-		var engineDownloadURL = fmt.Sprintf(targetedDomain+"/flutter_infra_release/flutter/%s/%s-x64/", requiredEngineVersion, targetOS)
+		platform := targetOS + "-" + build.FlutterArch(arch)
+		var engineFile string
 		switch targetOS {
 		case "darwin":
-			engineDownloadURL += "FlutterEmbedder.framework.zip"
+			engineFile = "FlutterEmbedder.framework.zip"
 		case "linux":
-			engineDownloadURL += targetOS + "-x64-embedder"
+			engineFile = platform + "-embedder"
 		case "windows":
-			engineDownloadURL += targetOS + "-x64-embedder.zip"
+			engineFile = platform + "-embedder.zip"
 		default:
-			log.Errorf("Cannot run on %s, download engine not implemented.", targetOS)
-			os.Exit(1)
+			return errors.Errorf("cannot run on %s, download engine not implemented", targetOS)
+		}
+		engineDownloadURL, err := artifactURL(mirror, newEngineArtifact(flutterSource, targetOS, arch, requiredEngineVersion, mode, engineFile))
+		if err != nil {
+			return err
+		}
+		artifactsDownloadURL, err := artifactURL(mirror, newEngineArtifact(flutterSource, targetOS, arch, requiredEngineVersion, mode, "artifacts.zip"))
+		if err != nil {
+			return err
 		}
 
-		artifactsZipPath := filepath.Join(dir, "artifacts.zip")
-		artifactsDownloadURL := fmt.Sprintf(targetedDomain+"/flutter_infra_release/flutter/%s/%s-x64/artifacts.zip", requiredEngineVersion, targetOS)
-
-		err = downloadFile(engineZipPath, engineDownloadURL)
+		engineZipPath, err := downloadFile(ctx, cachePath, engineDownloadURL)
 		if err != nil {
-			log.Errorf("Failed to download engine: %v", err)
-			os.Exit(1)
+			return errors.Wrap(err, "failed to download engine")
 		}
+		defer os.Remove(engineZipPath)
 		_, err = unzip(engineZipPath, engineExtractPath)
 		if err != nil {
 			log.Warnf("%v", err)
 		}
 
-		err = downloadFile(artifactsZipPath, artifactsDownloadURL)
+		artifactsZipPath, err := downloadFile(ctx, cachePath, artifactsDownloadURL)
 		if err != nil {
-			log.Errorf("Failed to download artifacts: %v", err)
-			os.Exit(1)
+			return errors.Wrap(err, "failed to download artifacts")
 		}
+		defer os.Remove(artifactsZipPath)
 		_, err = unzip(artifactsZipPath, engineExtractPath)
 		if err != nil {
 			log.Warnf("%v", err)
@@ -289,8 +279,7 @@ func ValidateOrUpdateEngine(targetOS, cachePath, requiredEngineVersion string, m
 			frameworkDestPath := filepath.Join(engineExtractPath, "FlutterEmbedder.framework")
 			_, err = unzip(frameworkZipPath, frameworkDestPath)
 			if err != nil {
-				log.Errorf("Failed to unzip engine framework: %v", err)
-				os.Exit(1)
+				return errors.Wrap(err, "failed to unzip engine framework")
 			}
 			createSymLink("A", frameworkDestPath+"/Versions/Current")
 			createSymLink("Versions/Current/FlutterEmbedder", frameworkDestPath+"/FlutterEmbedder")
@@ -308,17 +297,20 @@ func ValidateOrUpdateEngine(targetOS, cachePath, requiredEngineVersion string, m
 		case "windows":
 			file += "windows"
 		}
-		file += fmt.Sprintf("_x64-host_%s.zip", mode.Name)
-		engineDownloadURL := fmt.Sprintf("https://github.com/go-flutter-desktop/engine-builds/releases/download/f-%s/%s", requiredEngineVersion, file)
+		file += fmt.Sprintf("_%s-host_%s.zip", build.FlutterArch(arch), mode.Name)
+		engineDownloadURL, err := artifactURL(mirror, newEngineArtifact(goFlutterSource, targetOS, arch, requiredEngineVersion, mode, file))
+		if err != nil {
+			return err
+		}
 
-		err = downloadFile(engineZipPath, engineDownloadURL)
+		engineZipPath, err := downloadFile(ctx, cachePath, engineDownloadURL)
 		if err != nil {
-			log.Errorf("Failed to download engine: %v", err)
 			log.Errorf("Engine builds are a bit delayed after they are published in flutter.")
 			log.Errorf("You can either try again later or switch the flutter channel to beta, because these engines are more likely to be already built.")
 			log.Errorf("To dig into the already built engines look at https://github.com/go-flutter-desktop/engine-builds/releases and https://github.com/go-flutter-desktop/engine-builds/actions")
-			os.Exit(1)
+			return errors.Wrap(err, "failed to download engine")
 		}
+		defer os.Remove(engineZipPath)
 		_, err = unzip(engineZipPath, engineExtractPath)
 		if err != nil {
 			log.Warnf("%v", err)
@@ -329,26 +321,27 @@ func ValidateOrUpdateEngine(targetOS, cachePath, requiredEngineVersion string, m
 	for _, engineFile := range build.EngineFiles(targetOS, mode) {
 		err := copy.Copy(
 			filepath.Join(engineExtractPath, engineFile),
-			filepath.Join(engineCachePath, engineFile),
+			filepath.Join(stagingPath, engineFile),
 		)
 		if err != nil {
-			log.Errorf("Failed to copy downloaded %s: %v", engineFile, err)
-			os.Exit(1)
+			return errors.Wrapf(err, "failed to copy downloaded %s", engineFile)
 		}
 	}
 
 	// Strip linux engine after download and not at every build
-	if targetOS == "linux" && targetOS == runtime.GOOS {
-		unstrippedEngineFile := filepath.Join(engineCachePath, build.EngineFiles(targetOS, mode)[0])
-		err = exec.Command("strip", "-s", unstrippedEngineFile).Run()
+	if targetOS == runtime.GOOS && runtime.GOOS == "linux" && arch == runtime.GOARCH {
+		unstrippedEngineFile := filepath.Join(stagingPath, build.EngineFiles(targetOS, mode)[0])
+		err = exec.CommandContext(ctx, "strip", "-s", unstrippedEngineFile).Run()
 		if err != nil {
-			log.Errorf("Failed to strip %s: %v", unstrippedEngineFile, err)
-			os.Exit(1)
+			return errors.Wrapf(err, "failed to strip %s", unstrippedEngineFile)
 		}
 	}
 
 	if targetOS == "darwin" && mode != build.DebugMode {
-		darwinhacks.DyldHack(filepath.Join(engineCachePath, build.EngineFiles(targetOS, mode)[0]))
+		err = darwinhacks.DyldHack(ctx, filepath.Join(stagingPath, build.EngineFiles(targetOS, mode)[0]))
+		if err != nil {
+			return err
+		}
 	}
 
 	files := []string{
@@ -366,17 +359,30 @@ func ValidateOrUpdateEngine(targetOS, cachePath, requiredEngineVersion string, m
 	for _, file := range files {
 		err = copy.Copy(
 			filepath.Join(engineExtractPath, file),
-			filepath.Join(engineCachePath, file),
+			filepath.Join(stagingPath, file),
 		)
 		if err != nil {
-			log.Errorf("Failed to copy downloaded %s: %v", file, err)
-			os.Exit(1)
+			return errors.Wrapf(err, "failed to copy downloaded %s", file)
 		}
 	}
 
-	err = ioutil.WriteFile(cachedEngineVersionPath, []byte(fmt.Sprintf("%s-%s", requiredEngineVersion, version.HoverVersion())), 0664)
+	err = ioutil.WriteFile(filepath.Join(stagingPath, "version"), []byte(versionStamp(requiredEngineVersion)), 0664)
 	if err != nil {
-		log.Errorf("Failed to write version file: %v", err)
-		os.Exit(1)
+		return errors.Wrap(err, "failed to write version file")
 	}
+
+	// Replace the engine downloaded by another version of hover, if any.
+	err = os.RemoveAll(engineCachePath)
+	if err != nil {
+		return errors.Wrap(err, "failed to remove outdated engine")
+	}
+	err = os.Rename(stagingPath, engineCachePath)
+	if err != nil {
+		return errors.Wrap(err, "failed to install engine")
+	}
+	err = markEngineUsed(engineCachePath)
+	if err != nil {
+		log.Warnf("%v", err)
+	}
+	return nil
 }
@@ -0,0 +1,109 @@
+package enginecache
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// githubAPIURL is the base URL of the GitHub REST API, which publishes the
+// SHA-256 digests of the release assets.
+var githubAPIURL = "https://api.github.com"
+
+// githubReleaseDownload matches the download URLs of GitHub release assets.
+var githubReleaseDownload = regexp.MustCompile(`^https://github\.com/([^/]+)/([^/]+)/releases/download/([^/]+)/([^/]+)$`)
+
+// publishedChecksum returns the SHA-256 published for the file at url, or an
+// empty string when none is published. The digest of a GitHub release asset
+// is read from the GitHub API, other servers publish it as a sha256sum file
+// at url with the .sha256 extension, like mirrors can.
+func publishedChecksum(ctx context.Context, url string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	if m := githubReleaseDownload.FindStringSubmatch(url); m != nil {
+		return githubAssetDigest(ctx, m[1], m[2], m[3], m[4])
+	}
+	body, err := fetchChecksum(ctx, url+".sha256", nil)
+	if body == nil || err != nil {
+		return "", err
+	}
+	fields := strings.Fields(string(body))
+	if len(fields) == 0 || !isSHA256(fields[0]) {
+		return "", errors.Errorf("%s.sha256 isn't a sha256sum file", url)
+	}
+	return strings.ToLower(fields[0]), nil
+}
+
+// githubAssetDigest returns the SHA-256 digest of the asset of a GitHub
+// release, or an empty string when GitHub didn't compute it.
+func githubAssetDigest(ctx context.Context, owner, repo, tag, name string) (string, error) {
+	body, err := fetchChecksum(ctx, fmt.Sprintf("%s/repos/%s/%s/releases/tags/%s", githubAPIURL, owner, repo, tag), http.Header{
+		"Accept": {"application/vnd.github+json"},
+	})
+	if body == nil || err != nil {
+		return "", err
+	}
+	var release struct {
+		Assets []struct {
+			Name   string `json:"name"`
+			Digest string `json:"digest"`
+		} `json:"assets"`
+	}
+	err = json.Unmarshal(body, &release)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to decode the release %s of %s/%s", tag, owner, repo)
+	}
+	for _, asset := range release.Assets {
+		if asset.Name != name {
+			continue
+		}
+		digest := strings.TrimPrefix(asset.Digest, "sha256:")
+		if !isSHA256(digest) {
+			return "", nil
+		}
+		return strings.ToLower(digest), nil
+	}
+	return "", nil
+}
+
+// fetchChecksum returns the body of the response to a GET of url, or nil
+// when url doesn't exist.
+func fetchChecksum(ctx context.Context, url string, header http.Header) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("%s responded %s", url, resp.Status)
+	}
+	// the release of the engines lists a few dozens of assets
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", url)
+	}
+	return body, nil
+}
+
+func isSHA256(s string) bool {
+	b, err := hex.DecodeString(s)
+	return err == nil && len(b) == 32
+}
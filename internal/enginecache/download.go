@@ -0,0 +1,337 @@
+package enginecache
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/go-flutter-desktop/hover/internal/log"
+)
+
+// Downloads are written to a partial file in the engine cache, an interrupted
+// download is resumed with a HTTP Range request on the next attempt. A
+// completed download must match the SHA-256 published for it, when there is
+// one. Its SHA-256 is also recorded in a ledger next to the partial files,
+// later downloads of the same URL must match it. Without a published
+// checksum, the ledger only trusts the first download.
+var (
+	downloadAttempts     = 5
+	downloadRetryDelay   = 2 * time.Second
+	downloadStallTimeout = time.Minute
+	downloadClient       = newDownloadClient()
+)
+
+const checksumLedgerName = "checksums.sha256"
+
+func newDownloadClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = 30 * time.Second
+	// No overall timeout, engines are large. Stalled transfers are aborted
+	// by fetchPartial instead.
+	return &http.Client{Transport: transport}
+}
+
+// permanentError is returned for failures that retrying won't fix.
+type permanentError struct{ error }
+
+// downloadsPath returns the directory holding partial downloads and the
+// checksum ledger.
+func downloadsPath(cachePath string) string {
+	return filepath.Join(BaseEngineCachePath(cachePath), "downloads")
+}
+
+// downloadFile downloads url into the engine cache and returns the path of the
+// downloaded file. The caller is responsible for removing the file.
+func downloadFile(ctx context.Context, cachePath string, url string) (string, error) {
+	dir := downloadsPath(cachePath)
+	err := os.MkdirAll(dir, 0775)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create downloads directory")
+	}
+	urlHash := sha256.Sum256([]byte(url))
+	path := filepath.Join(dir, hex.EncodeToString(urlHash[:8]))
+	partialPath := path + ".partial"
+
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		err = fetchPartial(ctx, url, partialPath)
+		if err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return "", errors.Wrapf(ctx.Err(), "download of %s interrupted", url)
+		}
+		if _, ok := err.(*permanentError); ok || attempt == downloadAttempts {
+			return "", errors.Wrapf(err, "failed to download %s", url)
+		}
+		delay := downloadRetryDelay << (attempt - 1)
+		log.Warnf("Download of %s failed: %v", url, err)
+		log.Warnf("Retrying in %s (attempt %d of %d)", delay, attempt+1, downloadAttempts)
+		select {
+		case <-ctx.Done():
+			return "", errors.Wrapf(ctx.Err(), "download of %s interrupted", url)
+		case <-time.After(delay):
+		}
+	}
+	log.Printf("\033[2K\rDownload completed in %.2fs", time.Since(start).Seconds())
+
+	published, err := publishedChecksum(ctx, url)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", errors.Wrapf(ctx.Err(), "download of %s interrupted", url)
+		}
+		log.Warnf("Failed to get the published checksum of %s: %v", url, err)
+	}
+	err = verifyChecksum(filepath.Join(dir, checksumLedgerName), url, partialPath, published)
+	if err != nil {
+		// The partial file can't be trusted, start from scratch next time.
+		os.Remove(partialPath)
+		os.Remove(partialPath + ".validator")
+		return "", err
+	}
+	err = os.Rename(partialPath, path)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to move completed download")
+	}
+	os.Remove(partialPath + ".validator")
+	return path, nil
+}
+
+// fetchPartial downloads url into partialPath, continuing from the data
+// already present in partialPath when the server supports it. The ETag or
+// Last-Modified header of the response is kept next to the partial file, so
+// a resumed download never mixes two versions of the file.
+func fetchPartial(ctx context.Context, url, partialPath string) error {
+	validatorPath := partialPath + ".validator"
+	var offset int64
+	validator, err := ioutil.ReadFile(validatorPath)
+	if err == nil && len(validator) > 0 {
+		fi, err := os.Stat(partialPath)
+		if err == nil {
+			offset = fi.Size()
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return &permanentError{err}
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", string(validator))
+	}
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		if !strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)) {
+			os.Remove(validatorPath)
+			return errors.Errorf("server returned unexpected range %q", resp.Header.Get("Content-Range"))
+		}
+		log.Printf("Resuming download after %s", formatByteCount(offset))
+		flags |= os.O_APPEND
+	case resp.StatusCode == http.StatusOK:
+		offset = 0
+		flags |= os.O_TRUNC
+		err = writeValidator(validatorPath, resp.Header)
+		if err != nil {
+			return err
+		}
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		os.Remove(validatorPath)
+		return errors.Errorf("server responded %s", resp.Status)
+	default:
+		err := errors.Errorf("server responded %s", resp.Status)
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+			resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+			return &permanentError{err}
+		}
+		return err
+	}
+
+	out, err := os.OpenFile(partialPath, flags, 0664)
+	if err != nil {
+		return &permanentError{errors.Wrap(err, "failed to open partial download")}
+	}
+	defer out.Close()
+
+	var stalled atomic.Bool
+	stallTimer := time.AfterFunc(downloadStallTimeout, func() {
+		stalled.Store(true)
+		cancel()
+	})
+	defer stallTimer.Stop()
+
+	progress := &downloadProgress{written: offset, total: -1, stallTimer: stallTimer}
+	if resp.ContentLength >= 0 {
+		progress.total = offset + resp.ContentLength
+	}
+	_, err = io.Copy(out, io.TeeReader(resp.Body, progress))
+	progress.print()
+	fmt.Println("")
+	if err != nil {
+		if stalled.Load() {
+			return errors.Errorf("no data received for %s", downloadStallTimeout)
+		}
+		return err
+	}
+	if progress.total >= 0 && progress.written != progress.total {
+		return errors.Errorf("download ended after %d of %d bytes", progress.written, progress.total)
+	}
+	return out.Close()
+}
+
+// writeValidator records the header value used for the If-Range header when
+// resuming. Weak ETags can't be used in If-Range, in that case, and when the
+// server sent no validator at all, the download won't be resumed.
+func writeValidator(path string, header http.Header) error {
+	validator := header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = header.Get("Last-Modified")
+	}
+	if validator == "" {
+		err := os.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			return &permanentError{errors.Wrap(err, "failed to remove download validator")}
+		}
+		return nil
+	}
+	err := ioutil.WriteFile(path, []byte(validator), 0664)
+	if err != nil {
+		return &permanentError{errors.Wrap(err, "failed to write download validator")}
+	}
+	return nil
+}
+
+// downloadProgress prints the progress of a download as data is written to
+// it, and postpones the stall timeout on every write.
+type downloadProgress struct {
+	written    int64
+	total      int64 // -1 when the server didn't send a Content-Length
+	printed    time.Time
+	stallTimer *time.Timer
+}
+
+func (p *downloadProgress) Write(b []byte) (int, error) {
+	p.written += int64(len(b))
+	p.stallTimer.Reset(downloadStallTimeout)
+	if time.Since(p.printed) > time.Second/60 { // Flutter promises 60fps, right? ;)
+		p.print()
+	}
+	return len(b), nil
+}
+
+func (p *downloadProgress) print() {
+	p.printed = time.Now()
+	// We use '\033[2K\r' to avoid carriage return, it will print above previous.
+	if p.total <= 0 {
+		fmt.Printf("\033[2K\r %s", formatByteCount(p.written))
+		return
+	}
+	fmt.Printf("\033[2K\r %.0f %% / 100 %%", float64(p.written)/float64(p.total)*100)
+}
+
+func formatByteCount(n int64) string {
+	return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+}
+
+// verifyChecksum checks the SHA-256 of the file at path against published,
+// the checksum published for url, when it isn't empty, and against the
+// checksum recorded for url in the ledger otherwise. The checksum is recorded
+// when the ledger doesn't have this one for url yet.
+func verifyChecksum(ledgerPath, url, path, published string) error {
+	sum, err := fileSHA256(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to compute checksum of download")
+	}
+	if published != "" && published != sum {
+		return errors.Errorf("checksum mismatch for %s: published sha256 %s, got %s", url, published, sum)
+	}
+	checksums, err := readChecksumLedger(ledgerPath)
+	if err != nil {
+		return err
+	}
+	expected, ok := checksums[url]
+	if ok && expected != sum && published != "" {
+		// the file has been republished, the ledger keeps the last entry of url
+		log.Warnf("The checksum of %s changed, the published one is trusted", url)
+		ok = false
+	}
+	if !ok {
+		f, err := os.OpenFile(ledgerPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0664)
+		if err != nil {
+			return errors.Wrap(err, "failed to open checksum ledger")
+		}
+		defer f.Close()
+		_, err = fmt.Fprintf(f, "%s  %s\n", sum, url)
+		if err != nil {
+			return errors.Wrap(err, "failed to record checksum")
+		}
+		return f.Close()
+	}
+	if expected != sum {
+		log.Errorf("If the file has been republished on purpose, remove its entry from %s", ledgerPath)
+		return errors.Errorf("checksum mismatch for %s: expected sha256 %s, got %s", url, expected, sum)
+	}
+	return nil
+}
+
+// readChecksumLedger reads the ledger, which uses the sha256sum format with
+// the URL in place of the file name.
+func readChecksumLedger(ledgerPath string) (map[string]string, error) {
+	checksums := make(map[string]string)
+	f, err := os.Open(ledgerPath)
+	if os.IsNotExist(err) {
+		return checksums, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open checksum ledger")
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		// the last entry of a URL wins
+		checksums[fields[1]] = fields[0]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read checksum ledger")
+	}
+	return checksums, nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
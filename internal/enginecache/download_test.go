@@ -0,0 +1,181 @@
+package enginecache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var downloadContent = bytes.Repeat([]byte("go-flutter engine "), 4096)
+
+func init() {
+	downloadRetryDelay = time.Millisecond
+}
+
+func serveDownload(content []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".sha256") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("ETag", `"engine"`)
+		http.ServeContent(w, r, "engine.zip", time.Time{}, bytes.NewReader(content))
+	}
+}
+
+func TestDownloadFileResumesPartialDownload(t *testing.T) {
+	var rangeHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/engine.zip" {
+			rangeHeader = r.Header.Get("Range")
+		}
+		serveDownload(downloadContent)(w, r)
+	}))
+	defer server.Close()
+
+	cachePath := t.TempDir()
+	url := server.URL + "/engine.zip"
+	path, err := downloadFile(context.Background(), cachePath, url)
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(path))
+
+	// Simulate a download interrupted halfway.
+	half := int64(len(downloadContent) / 2)
+	require.NoError(t, os.MkdirAll(downloadsPath(cachePath), 0775))
+	require.NoError(t, ioutil.WriteFile(path+".partial", downloadContent[:half], 0664))
+	require.NoError(t, ioutil.WriteFile(path+".partial.validator", []byte(`"engine"`), 0664))
+
+	path, err = downloadFile(context.Background(), cachePath, url)
+	require.NoError(t, err)
+	require.Equal(t, fmt.Sprintf("bytes=%d-", half), rangeHeader)
+	downloaded, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, downloadContent, downloaded)
+}
+
+func TestDownloadFileRetries(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".sha256") {
+			http.NotFound(w, r)
+			return
+		}
+		if atomic.AddInt32(&requests, 1) < 3 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		// Flushing before writing the body leaves out the Content-Length.
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		w.Write(downloadContent)
+	}))
+	defer server.Close()
+
+	path, err := downloadFile(context.Background(), t.TempDir(), server.URL+"/engine.zip")
+	require.NoError(t, err)
+	require.EqualValues(t, 3, requests)
+	downloaded, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, downloadContent, downloaded)
+}
+
+func TestDownloadFileNotFound(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	url := server.URL + "/missing.zip"
+	_, err := downloadFile(context.Background(), t.TempDir(), url)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), url)
+	require.EqualValues(t, 1, requests, "a 404 must not be retried")
+}
+
+func TestDownloadFileChecksumMismatch(t *testing.T) {
+	content := downloadContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveDownload(content)(w, r)
+	}))
+	defer server.Close()
+
+	cachePath := t.TempDir()
+	url := server.URL + "/engine.zip"
+	_, err := downloadFile(context.Background(), cachePath, url)
+	require.NoError(t, err)
+	ledger, err := ioutil.ReadFile(filepath.Join(downloadsPath(cachePath), checksumLedgerName))
+	require.NoError(t, err)
+	require.Contains(t, string(ledger), url)
+
+	content = bytes.ToUpper(downloadContent)
+	_, err = downloadFile(context.Background(), cachePath, url)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "checksum mismatch for "+url)
+}
+
+func TestDownloadFilePublishedChecksum(t *testing.T) {
+	sum := sha256.Sum256(downloadContent)
+	published := hex.EncodeToString(sum[:])
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/engine.zip.sha256" {
+			fmt.Fprintf(w, "%s  engine.zip\n", published)
+			return
+		}
+		serveDownload(downloadContent)(w, r)
+	}))
+	defer server.Close()
+
+	cachePath := t.TempDir()
+	_, err := downloadFile(context.Background(), cachePath, server.URL+"/engine.zip")
+	require.NoError(t, err)
+
+	// the ledger is empty, only the published checksum rejects the file
+	published = strings.Repeat("0", 64)
+	_, err = downloadFile(context.Background(), t.TempDir(), server.URL+"/engine.zip")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "published sha256 "+published)
+}
+
+func TestGithubAssetDigest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/go-flutter-desktop/engine-builds/releases/tags/f-1234" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"assets": [
+			{"name": "linux_x64-host_debug_unopt.zip"},
+			{"name": "linux_x64-host_release.zip", "digest": "sha256:ABC0000000000000000000000000000000000000000000000000000000000def"}
+		]}`)
+	}))
+	defer server.Close()
+	defer func(url string) { githubAPIURL = url }(githubAPIURL)
+	githubAPIURL = server.URL
+
+	digest, err := githubAssetDigest(context.Background(), "go-flutter-desktop", "engine-builds", "f-1234", "linux_x64-host_release.zip")
+	require.NoError(t, err)
+	require.Equal(t, "abc0000000000000000000000000000000000000000000000000000000000def", digest)
+
+	// older releases have no digests
+	digest, err = githubAssetDigest(context.Background(), "go-flutter-desktop", "engine-builds", "f-1234", "linux_x64-host_debug_unopt.zip")
+	require.NoError(t, err)
+	require.Empty(t, digest)
+
+	digest, err = githubAssetDigest(context.Background(), "go-flutter-desktop", "engine-builds", "f-5678", "linux_x64-host_release.zip")
+	require.NoError(t, err)
+	require.Empty(t, digest)
+}
@@ -0,0 +1,261 @@
+package enginecache
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/go-flutter-desktop/hover/internal/version"
+)
+
+// lastUsedFile is touched every time a cached engine is used, its
+// modification time is the last-used time of the engine.
+const lastUsedFile = "last-used"
+
+// markEngineUsed records that the engine in engineCachePath has been used.
+func markEngineUsed(engineCachePath string) error {
+	path := filepath.Join(engineCachePath, lastUsedFile)
+	now := time.Now()
+	err := os.Chtimes(path, now, now)
+	if os.IsNotExist(err) {
+		err = ioutil.WriteFile(path, nil, 0664)
+	}
+	return errors.Wrap(err, "failed to record engine usage")
+}
+
+// CacheEntry is an engine in the engine cache.
+type CacheEntry struct {
+	// Name is the name of the cache directory, e.g. linux-release.
+	Name string
+	Path string
+	// OS is the target OS of the engine.
+	OS string
+	// EngineVersion and HoverVersion are empty when the engine download
+	// didn't complete.
+	EngineVersion string
+	HoverVersion  string
+	// Size is the size of the engine on disk, in bytes.
+	Size     int64
+	LastUsed time.Time
+}
+
+// ListCache returns the engines in the engine cache, sorted by engine version
+// and name.
+func ListCache(cachePath string) ([]CacheEntry, error) {
+	baseEngineCachePath := BaseEngineCachePath(cachePath)
+	versionDirs, err := cacheSubdirectories(baseEngineCachePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read engine cache directory")
+	}
+
+	var entries []CacheEntry
+	for _, versionDir := range versionDirs {
+		// The downloads and locks directories aren't engine versions.
+		if versionDir == filepath.Base(downloadsPath(cachePath)) || versionDir == filepath.Base(locksPath(cachePath)) {
+			continue
+		}
+		versionPath := filepath.Join(baseEngineCachePath, versionDir)
+		// Older versions of hover cached a single engine version directly
+		// in <os>-<mode> directories.
+		_, err := os.Stat(filepath.Join(versionPath, "version"))
+		if err == nil {
+			entry, err := readCacheEntry(versionPath)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+			continue
+		}
+		engineDirs, err := cacheSubdirectories(versionPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read engine cache directory")
+		}
+		for _, engineDir := range engineDirs {
+			entry, err := readCacheEntry(filepath.Join(versionPath, engineDir))
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// cacheSubdirectories returns the names of the directories in path, leaving
+// out the staging directories of engine installs, which start with a dot.
+func cacheSubdirectories(path string) ([]string, error) {
+	fileInfos, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, fileInfo := range fileInfos {
+		if fileInfo.IsDir() && !strings.HasPrefix(fileInfo.Name(), ".") {
+			names = append(names, fileInfo.Name())
+		}
+	}
+	return names, nil
+}
+
+func readCacheEntry(engineCachePath string) (CacheEntry, error) {
+	name := filepath.Base(engineCachePath)
+	entry := CacheEntry{
+		Name: name,
+		Path: engineCachePath,
+		OS:   strings.SplitN(name, "-", 2)[0],
+	}
+	fileInfo, err := os.Stat(engineCachePath)
+	if err != nil {
+		return entry, errors.Wrapf(err, "failed to read %s", engineCachePath)
+	}
+	entry.LastUsed = fileInfo.ModTime()
+	versionFileInfo, err := os.Stat(filepath.Join(engineCachePath, "version"))
+	if err == nil {
+		entry.LastUsed = versionFileInfo.ModTime()
+		cachedVersion, err := ioutil.ReadFile(filepath.Join(engineCachePath, "version"))
+		if err != nil {
+			return entry, errors.Wrapf(err, "failed to read the version of %s", engineCachePath)
+		}
+		entry.EngineVersion, entry.HoverVersion = splitVersionStamp(string(cachedVersion))
+	}
+	lastUsedFileInfo, err := os.Stat(filepath.Join(engineCachePath, lastUsedFile))
+	if err == nil {
+		entry.LastUsed = lastUsedFileInfo.ModTime()
+	}
+	entry.Size, err = directorySize(engineCachePath)
+	if err != nil {
+		return entry, errors.Wrapf(err, "failed to compute the size of %s", engineCachePath)
+	}
+	return entry, nil
+}
+
+// versionStamp returns the content of the version file of an engine at
+// engineVersion installed by this version of hover.
+func versionStamp(engineVersion string) string {
+	return fmt.Sprintf("%s-%s", engineVersion, version.HoverVersion())
+}
+
+// splitVersionStamp splits the content of the version file of a cached engine
+// into the engine version, which is a commit hash, and the version of hover
+// that downloaded it.
+func splitVersionStamp(stamp string) (engineVersion, hoverVersion string) {
+	versions := strings.SplitN(strings.TrimSpace(stamp), "-", 2)
+	if len(versions) == 2 {
+		return versions[0], versions[1]
+	}
+	return versions[0], ""
+}
+
+func removeEngine(ctx context.Context, cachePath string, entry CacheEntry) error {
+	lock, err := LockEngine(ctx, cachePath, entry.Path)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+	err = os.RemoveAll(entry.Path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to remove %s", entry.Name)
+	}
+	return nil
+}
+
+func directorySize(path string) (int64, error) {
+	var size int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// PrunePolicy selects the engines removed by PruneCache. An engine is removed
+// when it matches TargetOS and any of the other fields. When only TargetOS is
+// set, all engines of TargetOS are removed.
+type PrunePolicy struct {
+	// KeepVersions removes the engines whose version isn't one of the
+	// KeepVersions most recently used engine versions.
+	KeepVersions int
+	// OlderThan removes the engines that haven't been used for OlderThan.
+	OlderThan time.Duration
+	// TargetOS limits the pruning to the engines of TargetOS.
+	TargetOS string
+}
+
+// SelectPrune returns the entries that policy removes.
+func SelectPrune(entries []CacheEntry, policy PrunePolicy, now time.Time) []CacheEntry {
+	// Incomplete engines are left out of the version ranking.
+	versionLastUsed := make(map[string]time.Time)
+	for _, entry := range entries {
+		if entry.EngineVersion != "" && entry.LastUsed.After(versionLastUsed[entry.EngineVersion]) {
+			versionLastUsed[entry.EngineVersion] = entry.LastUsed
+		}
+	}
+	var versions []string
+	for version := range versionLastUsed {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versionLastUsed[versions[i]].After(versionLastUsed[versions[j]])
+	})
+	keptVersions := make(map[string]bool)
+	for i, version := range versions {
+		if i < policy.KeepVersions {
+			keptVersions[version] = true
+		}
+	}
+
+	var pruned []CacheEntry
+	for _, entry := range entries {
+		if policy.TargetOS != "" && entry.OS != policy.TargetOS {
+			continue
+		}
+		selectAll := policy.KeepVersions == 0 && policy.OlderThan == 0
+		tooOld := policy.OlderThan > 0 && now.Sub(entry.LastUsed) > policy.OlderThan
+		notKept := policy.KeepVersions > 0 && !keptVersions[entry.EngineVersion]
+		if selectAll || tooOld || notKept {
+			pruned = append(pruned, entry)
+		}
+	}
+	return pruned
+}
+
+// PruneCache removes the engines selected by policy from the engine cache and
+// returns them.
+func PruneCache(ctx context.Context, cachePath string, policy PrunePolicy, dryRun bool) ([]CacheEntry, error) {
+	entries, err := ListCache(cachePath)
+	if err != nil {
+		return nil, err
+	}
+	pruned := SelectPrune(entries, policy, time.Now())
+	if dryRun {
+		return pruned, nil
+	}
+	for _, entry := range pruned {
+		err = removeEngine(ctx, cachePath, entry)
+		if err != nil {
+			return nil, err
+		}
+		// Remove the directory of the engine version once its last engine
+		// is gone. This fails, as intended, when it isn't empty.
+		versionPath := filepath.Dir(entry.Path)
+		if versionPath != BaseEngineCachePath(cachePath) {
+			os.Remove(versionPath)
+		}
+	}
+	return pruned, nil
+}
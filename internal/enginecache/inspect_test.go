@@ -0,0 +1,36 @@
+package enginecache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectPrune(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	entries := []CacheEntry{
+		{Name: "darwin-release", OS: "darwin", EngineVersion: "aaa", LastUsed: now.Add(-40 * day)},
+		{Name: "linux-debug_unopt", OS: "linux", EngineVersion: "ccc", LastUsed: now.Add(-1 * day)},
+		{Name: "linux-release", OS: "linux", EngineVersion: "bbb", LastUsed: now.Add(-10 * day)},
+		{Name: "windows-debug_unopt", OS: "windows", EngineVersion: "aaa", LastUsed: now.Add(-35 * day)},
+		{Name: "windows-release", OS: "windows", LastUsed: now.Add(-2 * day)},
+	}
+	names := func(entries []CacheEntry) []string {
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name)
+		}
+		return names
+	}
+
+	require.Equal(t, []string{"darwin-release", "windows-debug_unopt", "windows-release"},
+		names(SelectPrune(entries, PrunePolicy{KeepVersions: 2}, now)))
+	require.Equal(t, []string{"darwin-release", "windows-debug_unopt"},
+		names(SelectPrune(entries, PrunePolicy{OlderThan: 30 * day}, now)))
+	require.Equal(t, []string{"windows-debug_unopt"},
+		names(SelectPrune(entries, PrunePolicy{OlderThan: 30 * day, TargetOS: "windows"}, now)))
+	require.Equal(t, []string{"linux-debug_unopt", "linux-release"},
+		names(SelectPrune(entries, PrunePolicy{TargetOS: "linux"}, now)))
+}
@@ -0,0 +1,96 @@
+package enginecache
+
+import (
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/go-flutter-desktop/hover/internal/build"
+)
+
+// MirrorEnv is the environment variable that sets the engine mirror. It takes
+// precedence over the engine-mirror field of hover.yaml.
+const MirrorEnv = "HOVER_ENGINE_MIRROR"
+
+// Sources of the engine artifacts. Debug engines are built by flutter, release
+// and profile engines by go-flutter.
+const (
+	flutterSource   = "flutter"
+	goFlutterSource = "go-flutter"
+)
+
+// The layouts of the artifact servers, relative to a mirror base URL. They
+// match the paths used by storage.googleapis.com and github.com, so a mirror
+// of both hosts can be served from a single base URL.
+const (
+	flutterLayout   = "/flutter_infra_release/flutter/{{.Revision}}/{{.Platform}}/{{.File}}"
+	goFlutterLayout = "/go-flutter-desktop/engine-builds/releases/download/f-{{.Revision}}/{{.File}}"
+)
+
+// engineArtifact is a file downloaded by ValidateOrUpdateEngine. Its fields
+// are available in mirror URL templates.
+type engineArtifact struct {
+	// Source is "flutter" for debug engines and artifacts.zip, "go-flutter"
+	// for release and profile engines.
+	Source string
+	// Revision is the flutter engine version.
+	Revision string
+	// OS is the target OS: linux, darwin or windows.
+	OS string
+	// Arch is the flutter name of the architecture: x64 or arm64.
+	Arch string
+	// Platform is the flutter name of the OS and architecture, e.g. linux-x64.
+	Platform string
+	// Mode is the name of the build mode: debug, release or profile.
+	Mode string
+	// File is the file name of the artifact.
+	File string
+}
+
+func newEngineArtifact(source, targetOS, arch, revision string, mode build.Mode, file string) engineArtifact {
+	return engineArtifact{
+		Source:   source,
+		Revision: revision,
+		OS:       targetOS,
+		Arch:     build.FlutterArch(arch),
+		Platform: targetOS + "-" + build.FlutterArch(arch),
+		Mode:     mode.Name,
+		File:     file,
+	}
+}
+
+// artifactURL returns the download URL of artifact. mirror is either empty,
+// a base URL under which the default layouts are served, or a Go text/template
+// of the full URL, e.g.
+//
+//	https://artifacts.example.com/{{.Source}}/{{.Revision}}/{{.File}}
+func artifactURL(mirror string, artifact engineArtifact) (string, error) {
+	urlTemplate := mirror
+	if !strings.Contains(mirror, "{{") {
+		base := strings.TrimSuffix(mirror, "/")
+		switch {
+		case artifact.Source == goFlutterSource && base == "":
+			urlTemplate = "https://github.com" + goFlutterLayout
+		case artifact.Source == goFlutterSource:
+			urlTemplate = base + goFlutterLayout
+		case base == "" && os.Getenv("FLUTTER_STORAGE_BASE_URL") != "":
+			urlTemplate = strings.TrimSuffix(os.Getenv("FLUTTER_STORAGE_BASE_URL"), "/") + flutterLayout
+		case base == "":
+			urlTemplate = "https://storage.googleapis.com" + flutterLayout
+		default:
+			urlTemplate = base + flutterLayout
+		}
+	}
+	tmpl, err := template.New("engine-mirror").Parse(urlTemplate)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse engine mirror %q", mirror)
+	}
+	var url strings.Builder
+	err = tmpl.Execute(&url, artifact)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to expand engine mirror %q", mirror)
+	}
+	return url.String(), nil
+}
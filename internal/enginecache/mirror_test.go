@@ -0,0 +1,34 @@
+package enginecache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-flutter-desktop/hover/internal/build"
+)
+
+func TestArtifactURL(t *testing.T) {
+	debugEngine := newEngineArtifact(flutterSource, "linux", "amd64", "abc123", build.DebugMode, "linux-x64-embedder")
+	releaseEngine := newEngineArtifact(goFlutterSource, "linux", "amd64", "abc123", build.ReleaseMode, "linux_x64-host_release.zip")
+
+	t.Setenv("FLUTTER_STORAGE_BASE_URL", "")
+	for _, tc := range []struct {
+		mirror   string
+		artifact engineArtifact
+		expected string
+	}{
+		{"", debugEngine, "https://storage.googleapis.com/flutter_infra_release/flutter/abc123/linux-x64/linux-x64-embedder"},
+		{"", releaseEngine, "https://github.com/go-flutter-desktop/engine-builds/releases/download/f-abc123/linux_x64-host_release.zip"},
+		{"https://mirror.local/", debugEngine, "https://mirror.local/flutter_infra_release/flutter/abc123/linux-x64/linux-x64-embedder"},
+		{"https://mirror.local", releaseEngine, "https://mirror.local/go-flutter-desktop/engine-builds/releases/download/f-abc123/linux_x64-host_release.zip"},
+		{"https://mirror.local/{{.Source}}/{{.Revision}}/{{.Mode}}/{{.File}}", releaseEngine, "https://mirror.local/go-flutter/abc123/release/linux_x64-host_release.zip"},
+	} {
+		url, err := artifactURL(tc.mirror, tc.artifact)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, url)
+	}
+
+	_, err := artifactURL("https://mirror.local/{{.Unknown}}", debugEngine)
+	require.Error(t, err)
+}
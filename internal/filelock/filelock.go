@@ -0,0 +1,63 @@
+// Package filelock provides exclusive locks shared between processes, used to
+// guard the engine cache against concurrent hover runs.
+package filelock
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// pollInterval is the time between two attempts to take a lock held by
+// another process.
+var pollInterval = 100 * time.Millisecond
+
+// Lock is an exclusive lock on a file.
+type Lock struct {
+	file *os.File
+}
+
+// Acquire takes the lock on the file at path, creating it when needed. When
+// another process holds the lock, Acquire calls waiting once and retries until
+// the lock is released or ctx is done. The lock is released when the process
+// exits, so a crashed process never leaves a stale lock behind.
+func Acquire(ctx context.Context, path string, waiting func()) (*Lock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0664)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open lock file %s", path)
+	}
+	for {
+		locked, err := tryLock(file)
+		if err != nil {
+			file.Close()
+			return nil, errors.Wrapf(err, "failed to lock %s", path)
+		}
+		if locked {
+			return &Lock{file: file}, nil
+		}
+		if waiting != nil {
+			waiting()
+			waiting = nil
+		}
+		select {
+		case <-ctx.Done():
+			file.Close()
+			return nil, errors.Wrapf(ctx.Err(), "failed to lock %s", path)
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// Release releases the lock. The lock file is left in place: if it were
+// removed, a process waiting on the removed file and a process creating a new
+// one could both take the lock.
+func (l *Lock) Release() error {
+	err := unlock(l.file)
+	closeErr := l.file.Close()
+	if err != nil {
+		return errors.Wrapf(err, "failed to unlock %s", l.file.Name())
+	}
+	return closeErr
+}
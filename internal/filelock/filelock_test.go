@@ -0,0 +1,39 @@
+package filelock
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcquireWaitsForRelease(t *testing.T) {
+	pollInterval = time.Millisecond
+	path := filepath.Join(t.TempDir(), "engine.lock")
+
+	lock, err := Acquire(context.Background(), path, nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	waited := false
+	_, err = Acquire(ctx, path, func() { waited = true })
+	require.Error(t, err, "the lock is held")
+	require.True(t, waited)
+
+	acquired := make(chan *Lock)
+	go func() {
+		lock, err := Acquire(context.Background(), path, nil)
+		require.NoError(t, err)
+		acquired <- lock
+	}()
+	require.NoError(t, lock.Release())
+	select {
+	case lock := <-acquired:
+		require.NoError(t, lock.Release())
+	case <-time.After(time.Second):
+		t.Fatal("the lock wasn't acquired after its release")
+	}
+}
@@ -11,7 +11,7 @@ import (
 	"strings"
 	"text/template"
 
-	"github.com/go-flutter-desktop/hover/internal/log"
+	"github.com/pkg/errors"
 )
 
 // IsFileExists checks if a file exists and is not a directory
@@ -33,11 +33,10 @@ func IsDirectory(path string) bool {
 }
 
 // RemoveLinesFromFile removes lines to a file if the text is present in the line
-func RemoveLinesFromFile(filePath, text string) {
+func RemoveLinesFromFile(filePath, text string) error {
 	input, err := ioutil.ReadFile(filePath)
 	if err != nil {
-		log.Errorf("Failed to read file %s: %v\n", filePath, err)
-		os.Exit(1)
+		return errors.Wrapf(err, "failed to read file %s", filePath)
 	}
 
 	lines := strings.Split(string(input), "\n")
@@ -51,25 +50,23 @@ func RemoveLinesFromFile(filePath, text string) {
 	output := strings.Join(tmp, "\n")
 	err = ioutil.WriteFile(filePath, []byte(output), 0644)
 	if err != nil {
-		log.Errorf("Failed to write file %s: %v\n", filePath, err)
-		os.Exit(1)
+		return errors.Wrapf(err, "failed to write file %s", filePath)
 	}
+	return nil
 }
 
 // AddLineToFile appends a newLine to a file if the line isn't
 // already present.
-func AddLineToFile(filePath, newLine string) {
+func AddLineToFile(filePath, newLine string) error {
 	f, err := os.OpenFile(filePath,
 		os.O_RDWR|os.O_APPEND, 0660)
 	if err != nil {
-		log.Errorf("Failed to open file %s: %v\n", filePath, err)
-		os.Exit(1)
+		return errors.Wrapf(err, "failed to open file %s", filePath)
 	}
 	defer f.Close()
 	content, err := ioutil.ReadAll(f)
 	if err != nil {
-		log.Errorf("Failed to read file %s: %v\n", filePath, err)
-		os.Exit(1)
+		return errors.Wrapf(err, "failed to read file %s", filePath)
 	}
 	lines := make(map[string]struct{})
 	for _, w := range strings.Split(string(content), "\n") {
@@ -77,70 +74,69 @@ func AddLineToFile(filePath, newLine string) {
 	}
 	_, ok := lines[newLine]
 	if ok {
-		return
+		return nil
 	}
 	if _, err := f.WriteString(newLine + "\n"); err != nil {
-		log.Errorf("Failed to append '%s' to the file (%s): %v\n", newLine, filePath, err)
-		os.Exit(1)
+		return errors.Wrapf(err, "failed to append '%s' to the file (%s)", newLine, filePath)
 	}
+	return nil
 }
 
 // CopyFile from one file to another
-func CopyFile(src, to string) {
+func CopyFile(src, to string) error {
 	in, err := os.Open(src)
 	if err != nil {
-		log.Errorf("Failed to read %s: %v\n", src, err)
-		os.Exit(1)
+		return errors.Wrapf(err, "failed to read %s", src)
 	}
 	defer in.Close()
 	file, err := os.Create(to)
 	if err != nil {
-		log.Errorf("Failed to create %s: %v\n", to, err)
-		os.Exit(1)
+		return errors.Wrapf(err, "failed to create %s", to)
 	}
 	defer file.Close()
 
 	_, err = io.Copy(file, in)
 	if err != nil {
-		log.Errorf("Failed to copy %s to %s: %v\n", src, to, err)
-		os.Exit(1)
+		return errors.Wrapf(err, "failed to copy %s to %s", src, to)
 	}
+	return nil
 }
 
 // CopyDir copy files from one directory to another directory recursively
-func CopyDir(src, dst string) {
+func CopyDir(src, dst string) error {
 	var err error
 	var fds []os.FileInfo
 
 	if !IsDirectory(src) {
-		log.Errorf("Failed to copy directory, %s not a directory\n", src)
-		os.Exit(1)
+		return errors.Errorf("failed to copy directory, %s not a directory", src)
 	}
 
 	if err = os.MkdirAll(dst, 0755); err != nil {
-		log.Errorf("Failed to copy directory %s to %s: %v\n", src, dst, err)
-		os.Exit(1)
+		return errors.Wrapf(err, "failed to copy directory %s to %s", src, dst)
 	}
 
 	if fds, err = ioutil.ReadDir(src); err != nil {
-		log.Errorf("Failed to list directory %s: %v\n", src, err)
-		os.Exit(1)
+		return errors.Wrapf(err, "failed to list directory %s", src)
 	}
 
 	for _, fd := range fds {
 		srcPath := filepath.Join(src, fd.Name())
 		dstPath := filepath.Join(dst, fd.Name())
 		if fd.IsDir() {
-			CopyDir(srcPath, dstPath)
+			err = CopyDir(srcPath, dstPath)
 		} else {
-			CopyFile(srcPath, dstPath)
+			err = CopyFile(srcPath, dstPath)
+		}
+		if err != nil {
+			return err
 		}
 	}
+	return nil
 }
 
 // CopyTemplateDir copy files from one directory to another directory recursively
 // while executing all templates in files and file names
-func CopyTemplateDir(boxed, to string, templateData interface{}) {
+func CopyTemplateDir(boxed, to string, templateData interface{}) error {
 	var files []string
 	err := filepath.Walk(boxed, func(path string, info os.FileInfo, err error) error {
 		files = append(files, path)
@@ -148,113 +144,110 @@ func CopyTemplateDir(boxed, to string, templateData interface{}) {
 	})
 	files = files[1:]
 	if err != nil {
-		log.Errorf("Failed to list files in directory %s: %v\n", boxed, err)
-		os.Exit(1)
+		return errors.Wrapf(err, "failed to list files in directory %s", boxed)
 	}
 	for _, file := range files {
 		newFile := filepath.Join(to, strings.Join(strings.Split(file, "")[len(boxed)+1:], ""))
 		tmplFile, err := template.New("").Option("missingkey=error").Parse(newFile)
 		if err != nil {
-			log.Errorf("Failed to parse template string: %v\n", err)
-			os.Exit(1)
+			return errors.Wrap(err, "failed to parse template string")
 		}
 		var tmplBytes bytes.Buffer
 		err = tmplFile.Execute(&tmplBytes, templateData)
 		if err != nil {
-			panic(err)
+			return errors.Wrapf(err, "failed to execute template string %s", newFile)
 		}
 		newFile = tmplBytes.String()
 		fi, err := os.Stat(file)
 		if err != nil {
-			fmt.Println(err)
-			return
+			return errors.Wrapf(err, "failed to stat %s", file)
 		}
 		switch mode := fi.Mode(); {
 		case mode.IsDir():
 			err := os.MkdirAll(newFile, 0755)
 			if err != nil {
-				log.Errorf("Failed to create directory %s: %v\n", newFile, err)
-				os.Exit(1)
+				return errors.Wrapf(err, "failed to create directory %s", newFile)
 			}
 		case mode.IsRegular():
 			if strings.HasSuffix(newFile, ".tmpl") {
 				newFile = strings.TrimSuffix(newFile, ".tmpl")
 			}
-			ExecuteTemplateFromFile(file, newFile, templateData)
+			err = ExecuteTemplateFromFile(file, newFile, templateData)
+			if err != nil {
+				return err
+			}
 		}
 	}
+	return nil
 }
 
-func executeTemplateFromString(templateString, to string, templateData interface{}) {
+func executeTemplateFromString(templateString, to string, templateData interface{}) error {
 	tmplFile, err := template.New("").Option("missingkey=error").Parse(templateString)
 	if err != nil {
-		log.Errorf("Failed to parse template string: %v\n", err)
-		os.Exit(1)
+		return errors.Wrap(err, "failed to parse template string")
 	}
 
 	toFile, err := os.Create(to)
 	if err != nil {
-		log.Errorf("Failed to create '%s': %v\n", to, err)
-		os.Exit(1)
+		return errors.Wrapf(err, "failed to create '%s'", to)
 	}
 	defer toFile.Close()
 
-	tmplFile.Execute(toFile, templateData)
+	err = tmplFile.Execute(toFile, templateData)
+	if err != nil {
+		return errors.Wrapf(err, "failed to execute template for '%s'", to)
+	}
+	return nil
 }
 
 // ExecuteTemplateFromFile create file from a template file
-func ExecuteTemplateFromFile(boxed, to string, templateData interface{}) {
+func ExecuteTemplateFromFile(boxed, to string, templateData interface{}) error {
 	templateString, err := ioutil.ReadFile(boxed)
 	if err != nil {
-		log.Errorf("Failed to find template file: %v\n", err)
-		os.Exit(1)
+		return errors.Wrap(err, "failed to find template file")
 	}
-	executeTemplateFromString(string(templateString), to, templateData)
+	return executeTemplateFromString(string(templateString), to, templateData)
 }
 
 // ExecuteTemplateFromAssets create file from a template asset
-func ExecuteTemplateFromAssets(name, to string, templateData interface{}) {
+func ExecuteTemplateFromAssets(name, to string, templateData interface{}) error {
 	data, err := assets.ReadFile(fmt.Sprintf("assets/%s", name))
 	if err != nil {
-		log.Errorf("Failed to find template file: %v\n", err)
-		os.Exit(1)
+		return errors.Wrap(err, "failed to find template file")
 	}
-	executeTemplateFromString(string(data), to, templateData)
+	return executeTemplateFromString(string(data), to, templateData)
 }
 
 // CopyAsset copies a file from asset
-func CopyAsset(name, to string) {
+func CopyAsset(name, to string) error {
 	data, err := assets.ReadFile(fmt.Sprintf("assets/%s", name))
 	if err != nil {
-		log.Errorf("Failed to find asset file %s: %v", name, err)
-		os.Exit(1)
+		return errors.Wrapf(err, "failed to find asset file %s", name)
 	}
 	err = os.WriteFile(to, data, 0o600)
 	if err != nil {
-		log.Errorf("Failed to write file %s: %v", to, err)
-		os.Exit(1)
+		return errors.Wrapf(err, "failed to write file %s", to)
 	}
+	return nil
 }
 
 // DownloadFile will download a url to a local file.
-func DownloadFile(url string, filepath string) {
+func DownloadFile(url string, filepath string) error {
 	resp, err := http.Get(url)
 	if err != nil {
-		log.Errorf("Failed to download '%v': %v\n", url, err)
-		os.Exit(1)
+		return errors.Wrapf(err, "failed to download '%v'", url)
 	}
 	defer resp.Body.Close()
 
 	out, err := os.Create(filepath)
 	if err != nil {
-		log.Errorf("Failed to create file '%s': %v\n", filepath, err)
-		os.Exit(1)
+		return errors.Wrapf(err, "failed to create file '%s'", filepath)
 	}
 	defer out.Close()
 
 	_, err = io.Copy(out, resp.Body)
 	if err != nil {
-		log.Errorf("Failed to write file '%s': %v\n", filepath, err)
-		os.Exit(1)
+		return errors.Wrapf(err, "failed to write file '%s'", filepath)
 	}
+	return nil
 }
@@ -0,0 +1,289 @@
+// Package hotreload reloads the dart code of a running debug app. The
+// changed sources are compiled by a resident frontend_server and the kernel
+// is loaded in the app through its VM service.
+package hotreload
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/go-flutter-desktop/hover/internal/build"
+)
+
+// CompilerOptions configures the frontend_server of a Compiler.
+type CompilerOptions struct {
+	// Target is the main dart file of the app.
+	Target string
+	// DartDefines are the KEY=VALUE compile-time constants of the dart code.
+	DartDefines []string
+	// OutputDill is the kernel file written by the compilations.
+	OutputDill string
+}
+
+// CompileResult is the result of a compilation by the frontend_server.
+type CompileResult struct {
+	// OutputDill is the kernel file written by the compilation, it's empty
+	// when the compilation failed.
+	OutputDill string
+	// Errors is the number of compilation errors.
+	Errors int
+	// Diagnostics are the errors and warnings printed by the compiler.
+	Diagnostics []string
+}
+
+// CompileError is returned when the dart code doesn't compile.
+type CompileError struct {
+	Diagnostics []string
+}
+
+func (e *CompileError) Error() string {
+	return "compilation failed:\n" + strings.Join(e.Diagnostics, "\n")
+}
+
+// Compiler is a resident frontend_server compiling the dart code of the app
+// incrementally. Its methods must not be called concurrently.
+type Compiler struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Scanner
+	opts   CompilerOptions
+
+	closeOnce sync.Once
+}
+
+// frontendServer returns the command running the frontend_server of the
+// flutter SDK. Newer SDKs ship it as an AOT snapshot in the dart SDK, older
+// ones as a JIT snapshot in the engine artifacts.
+func frontendServer() ([]string, string, error) {
+	flutterBin, err := build.FlutterBin()
+	if err != nil {
+		return nil, "", err
+	}
+	flutterBin, err = filepath.EvalSymlinks(flutterBin)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to resolve the flutter executable")
+	}
+	cache := filepath.Join(filepath.Dir(filepath.Dir(flutterBin)), "bin", "cache")
+	dartSdkBin := filepath.Join(cache, "dart-sdk", "bin")
+	patchedSdk := filepath.Join(cache, "artifacts", "engine", "common", "flutter_patched_sdk")
+
+	executable := build.ExecutableExtension(runtime.GOOS)
+	candidates := [][]string{
+		{filepath.Join(dartSdkBin, "dartaotruntime"+executable), filepath.Join(dartSdkBin, "snapshots", "frontend_server_aot.dart.snapshot")},
+		{filepath.Join(dartSdkBin, "dart"+executable), filepath.Join(dartSdkBin, "snapshots", "frontend_server.dart.snapshot")},
+		{filepath.Join(dartSdkBin, "dart"+executable), filepath.Join(cache, "artifacts", "engine", runtime.GOOS+"-x64", "frontend_server.dart.snapshot")},
+	}
+	for _, candidate := range candidates {
+		if fileExists(candidate[0]) && fileExists(candidate[1]) {
+			return candidate, patchedSdk, nil
+		}
+	}
+	return nil, "", errors.Errorf("the frontend_server of the flutter SDK wasn't found in %s, run `flutter precache`", cache)
+}
+
+// packagesPath returns the package file of the app, relative to the working
+// directory.
+func packagesPath() string {
+	packageConfig := filepath.Join(".dart_tool", "package_config.json")
+	if fileExists(packageConfig) {
+		return packageConfig
+	}
+	return ".packages"
+}
+
+// StartCompiler starts the frontend_server of the flutter SDK. It's stopped
+// when ctx is done or Close is called.
+func StartCompiler(ctx context.Context, opts CompilerOptions) (*Compiler, error) {
+	command, patchedSdk, err := frontendServer()
+	if err != nil {
+		return nil, err
+	}
+	args := append(command[1:],
+		"--sdk-root="+patchedSdk,
+		"--incremental",
+		"--target=flutter",
+		"-Ddart.vm.profile=false",
+		"-Ddart.vm.product=false",
+		"--track-widget-creation",
+		"--packages="+packagesPath(),
+		"--output-dill="+opts.OutputDill,
+	)
+	for _, define := range opts.DartDefines {
+		args = append(args, "-D"+define)
+	}
+	cmd := exec.CommandContext(ctx, command[0], args...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create stdin pipe on frontend_server")
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create stdout pipe on frontend_server")
+	}
+	err = cmd.Start()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start frontend_server")
+	}
+	return &Compiler{
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: bufio.NewScanner(stdout),
+		opts:   opts,
+	}, nil
+}
+
+// Compile compiles the whole app. It's the first compilation of the
+// compiler, or the first one after Reset.
+func (c *Compiler) Compile() (CompileResult, error) {
+	target, err := filepath.Abs(c.opts.Target)
+	if err != nil {
+		return CompileResult{}, errors.Wrap(err, "failed to resolve the target")
+	}
+	return c.request(true, "compile "+target)
+}
+
+// Recompile compiles the libraries of the invalidated files, and those
+// depending on them, into a kernel which can be loaded over the previous
+// one.
+func (c *Compiler) Recompile(invalidated []string) (CompileResult, error) {
+	target, err := filepath.Abs(c.opts.Target)
+	if err != nil {
+		return CompileResult{}, errors.Wrap(err, "failed to resolve the target")
+	}
+	boundary := strconv.FormatInt(rand.Int63(), 36)
+	lines := []string{"recompile " + target + " " + boundary}
+	for _, path := range invalidated {
+		path, err = filepath.Abs(path)
+		if err != nil {
+			return CompileResult{}, errors.Wrap(err, "failed to resolve an invalidated file")
+		}
+		lines = append(lines, fileURI(path))
+	}
+	lines = append(lines, boundary)
+	return c.request(true, lines...)
+}
+
+// Accept tells the compiler the last kernel was loaded by the app, the
+// next compilations are relative to it.
+func (c *Compiler) Accept() error {
+	return c.send("accept")
+}
+
+// Reject tells the compiler the last kernel wasn't loaded by the app, the
+// next compilations are relative to the previous accepted one.
+func (c *Compiler) Reject() error {
+	err := c.send("reject")
+	if err != nil {
+		return err
+	}
+	_, err = c.readResult(false)
+	return err
+}
+
+// Reset makes the next compilation a full one, as needed to restart the
+// app.
+func (c *Compiler) Reset() error {
+	return c.send("reset")
+}
+
+// Close stops the frontend_server.
+func (c *Compiler) Close() error {
+	c.closeOnce.Do(func() {
+		c.send("quit")
+		c.stdin.Close()
+		c.cmd.Wait()
+	})
+	return nil
+}
+
+func (c *Compiler) send(lines ...string) error {
+	_, err := io.WriteString(c.stdin, strings.Join(lines, "\n")+"\n")
+	return errors.Wrap(err, "failed to write to frontend_server")
+}
+
+func (c *Compiler) request(expectSources bool, lines ...string) (CompileResult, error) {
+	err := c.send(lines...)
+	if err != nil {
+		return CompileResult{}, err
+	}
+	return c.readResult(expectSources)
+}
+
+// readResult reads the output of a request:
+//
+//	result <key>
+//	<diagnostics>
+//	<key>
+//	+<added source>
+//	-<removed source>
+//	<key> <output dill> <error count>
+//
+// The list of sources is only printed by the compilations, and the output
+// dill is missing when the compilation failed.
+func (c *Compiler) readResult(expectSources bool) (CompileResult, error) {
+	var result CompileResult
+	key := ""
+	inSources := false
+	for c.stdout.Scan() {
+		line := c.stdout.Text()
+		switch {
+		case key == "":
+			if strings.HasPrefix(line, "result ") {
+				key = strings.TrimPrefix(line, "result ")
+			}
+			continue
+		case !strings.HasPrefix(line, key):
+			if !inSources {
+				result.Diagnostics = append(result.Diagnostics, line)
+			}
+			continue
+		case expectSources && !inSources:
+			inSources = true
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, key))
+		if len(fields) < 2 {
+			// the compilation failed
+			result.Errors = len(result.Diagnostics)
+			return result, nil
+		}
+		var err error
+		result.OutputDill = strings.Join(fields[:len(fields)-1], " ")
+		result.Errors, err = strconv.Atoi(fields[len(fields)-1])
+		if err != nil {
+			return result, errors.Errorf("invalid output of frontend_server: %q", line)
+		}
+		return result, nil
+	}
+	if err := c.stdout.Err(); err != nil {
+		return result, errors.Wrap(err, "failed to read the output of frontend_server")
+	}
+	return result, errors.New("frontend_server exited")
+}
+
+// fileURI returns the file URI of an absolute path.
+func fileURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		// windows drive letter
+		path = "/" + path
+	}
+	return "file://" + path
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,53 @@
+package hotreload
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// outputCompiler returns a compiler reading the output of a frontend_server.
+func outputCompiler(output string) *Compiler {
+	return &Compiler{stdout: bufio.NewScanner(strings.NewReader(output))}
+}
+
+func TestReadResult(t *testing.T) {
+	result, err := outputCompiler(`result 7f0c
+7f0c
++file:///app/lib/main.dart
++file:///app/lib/counter.dart
+7f0c /tmp/hover-hotreload/app.dill 0
+`).readResult(true)
+	require.NoError(t, err)
+	require.Equal(t, "/tmp/hover-hotreload/app.dill", result.OutputDill)
+	require.Equal(t, 0, result.Errors)
+	require.Empty(t, result.Diagnostics)
+
+	result, err = outputCompiler(`result 2a9d
+lib/main.dart:12:5: Error: Expected ';' after this.
+    foo()
+    ^^^
+2a9d
+2a9d
+`).readResult(true)
+	require.NoError(t, err)
+	require.Empty(t, result.OutputDill)
+	require.Equal(t, []string{
+		"lib/main.dart:12:5: Error: Expected ';' after this.",
+		"    foo()",
+		"    ^^^",
+	}, result.Diagnostics)
+
+	// reject doesn't list the sources
+	_, err = outputCompiler("result 91be\n91be\n").readResult(false)
+	require.NoError(t, err)
+
+	_, err = outputCompiler("result 91be\n").readResult(true)
+	require.Error(t, err)
+}
+
+func TestFileURI(t *testing.T) {
+	require.Equal(t, "file:///app/lib/main.dart", fileURI("/app/lib/main.dart"))
+}
@@ -0,0 +1,240 @@
+package hotreload
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/go-flutter-desktop/hover/internal/vmservice"
+	"github.com/go-flutter-desktop/hover/internal/watch"
+)
+
+// SourceDirectory is the directory of the dart sources of the app.
+const SourceDirectory = "lib"
+
+// DartSources selects the dart sources of the app for watch.
+func DartSources(path string, info os.FileInfo) bool {
+	return info.IsDir() || strings.HasSuffix(path, ".dart")
+}
+
+// Options configures a Reloader.
+type Options struct {
+	// Target is the main dart file of the app.
+	Target string
+	// DartDefines are the KEY=VALUE compile-time constants of the dart code,
+	// they must be those of the build.
+	DartDefines []string
+	// AssetDirectory is the flutter_assets directory of the app.
+	AssetDirectory string
+}
+
+// Report describes a reload or a restart of the app.
+type Report struct {
+	// Files is the number of dart files which changed.
+	Files int
+	// Compile is the duration of the compilation.
+	Compile time.Duration
+	// Reload is the duration of the reload in the app.
+	Reload time.Duration
+}
+
+// Reloader reloads the changes of the dart sources in the app it's attached
+// to. It outlives the restarts of the app, its methods can be called
+// concurrently.
+type Reloader struct {
+	opts     Options
+	compiler *Compiler
+	tempDir  string
+
+	mu      sync.Mutex
+	client  *vmservice.Client
+	sources watch.Snapshot
+	// reloaded is set once the app runs code newer than its flutter bundle,
+	// a relaunched app must then be restarted.
+	reloaded bool
+}
+
+// NewReloader starts the compiler and compiles the app, as it was bundled.
+// The reloader must be attached to the app with Attach.
+func NewReloader(ctx context.Context, opts Options) (*Reloader, error) {
+	tempDir, err := ioutil.TempDir("", "hover-hotreload")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the hot reload directory")
+	}
+	sources := watch.TakeSnapshot([]string{SourceDirectory}, DartSources)
+	compiler, err := StartCompiler(ctx, CompilerOptions{
+		Target:      opts.Target,
+		DartDefines: opts.DartDefines,
+		OutputDill:  filepath.Join(tempDir, "app.dill"),
+	})
+	if err != nil {
+		os.RemoveAll(tempDir)
+		return nil, err
+	}
+	r := &Reloader{
+		opts:     opts,
+		compiler: compiler,
+		tempDir:  tempDir,
+		sources:  sources,
+	}
+	result, err := compiler.Compile()
+	if err == nil && result.OutputDill == "" {
+		err = &CompileError{Diagnostics: result.Diagnostics}
+	}
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	err = compiler.Accept()
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Attach connects the reloader to the VM service of an app started from the
+// flutter bundle. The app is restarted when the sources were reloaded since
+// the bundle was built.
+func (r *Reloader) Attach(ctx context.Context, client *vmservice.Client) (*Report, error) {
+	r.mu.Lock()
+	r.client = client
+	reloaded := r.reloaded
+	r.mu.Unlock()
+	if !reloaded {
+		return nil, nil
+	}
+	return r.Restart(ctx)
+}
+
+// Reload compiles the dart files changed since the last reload and loads
+// them in the app. The state of the app is kept.
+func (r *Reloader) Reload(ctx context.Context) (*Report, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.client == nil {
+		return nil, errors.New("not connected to the app")
+	}
+
+	sources := watch.TakeSnapshot([]string{SourceDirectory}, DartSources)
+	changed := r.sources.Changed(sources)
+	report := &Report{Files: len(changed)}
+
+	start := time.Now()
+	result, err := r.compiler.Recompile(changed)
+	if err != nil {
+		return nil, err
+	}
+	report.Compile = time.Since(start)
+	if result.OutputDill == "" || result.Errors > 0 {
+		return nil, r.reject(&CompileError{Diagnostics: result.Diagnostics})
+	}
+
+	start = time.Now()
+	views, err := r.client.ListViews(ctx)
+	if err != nil {
+		return nil, r.reject(err)
+	}
+	reloadedIsolates := make(map[string]bool)
+	for _, view := range views {
+		if view.Isolate == nil || reloadedIsolates[view.Isolate.ID] {
+			continue
+		}
+		reloadReport, err := r.client.ReloadSources(ctx, view.Isolate.ID, fileURI(result.OutputDill))
+		if err == nil && !reloadReport.Success {
+			err = errors.Errorf("the app rejected the reload: %s", reloadReport.Reasons())
+		}
+		if err != nil {
+			return nil, r.reject(err)
+		}
+		reloadedIsolates[view.Isolate.ID] = true
+	}
+	err = r.compiler.Accept()
+	if err != nil {
+		return nil, err
+	}
+	r.sources = sources
+	r.reloaded = true
+
+	for isolateID := range reloadedIsolates {
+		err = r.client.CallServiceExtension(ctx, isolateID, "ext.flutter.reassemble", nil, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	report.Reload = time.Since(start)
+	return report, nil
+}
+
+// Restart compiles the whole app and runs it from its main function. The
+// state of the app is lost.
+func (r *Reloader) Restart(ctx context.Context) (*Report, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.client == nil {
+		return nil, errors.New("not connected to the app")
+	}
+
+	sources := watch.TakeSnapshot([]string{SourceDirectory}, DartSources)
+	report := &Report{Files: len(r.sources.Changed(sources))}
+
+	start := time.Now()
+	err := r.compiler.Reset()
+	if err != nil {
+		return nil, err
+	}
+	result, err := r.compiler.Recompile(nil)
+	if err != nil {
+		return nil, err
+	}
+	report.Compile = time.Since(start)
+	if result.OutputDill == "" || result.Errors > 0 {
+		return nil, r.reject(&CompileError{Diagnostics: result.Diagnostics})
+	}
+
+	start = time.Now()
+	assetDirectory, err := filepath.Abs(r.opts.AssetDirectory)
+	if err != nil {
+		return nil, r.reject(errors.Wrap(err, "failed to resolve the flutter_assets directory"))
+	}
+	views, err := r.client.ListViews(ctx)
+	if err != nil {
+		return nil, r.reject(err)
+	}
+	for _, view := range views {
+		err = r.client.RunInView(ctx, view.ID, fileURI(result.OutputDill), fileURI(assetDirectory))
+		if err != nil {
+			return nil, r.reject(err)
+		}
+	}
+	err = r.compiler.Accept()
+	if err != nil {
+		return nil, err
+	}
+	r.sources = sources
+	r.reloaded = true
+	report.Reload = time.Since(start)
+	return report, nil
+}
+
+// reject tells the compiler the last kernel wasn't loaded by the app, and
+// returns err, or the error of the compiler.
+func (r *Reloader) reject(err error) error {
+	rejectErr := r.compiler.Reject()
+	if rejectErr != nil {
+		return rejectErr
+	}
+	return err
+}
+
+// Close stops the compiler and removes its outputs.
+func (r *Reloader) Close() error {
+	r.compiler.Close()
+	return errors.Wrap(os.RemoveAll(r.tempDir), "failed to remove the hot reload directory")
+}
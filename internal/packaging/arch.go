@@ -0,0 +1,28 @@
+package packaging
+
+// debArch returns the debian name of a GOARCH. Also used by snap.
+func debArch(arch string) string {
+	return arch
+}
+
+// rpmArch returns the rpm name of a GOARCH. Also used by AppImage and pacman.
+func rpmArch(arch string) string {
+	switch arch {
+	case "amd64":
+		return "x86_64"
+	case "arm64":
+		return "aarch64"
+	default:
+		return arch
+	}
+}
+
+// msiArch returns the WiX name of a GOARCH.
+func msiArch(arch string) string {
+	switch arch {
+	case "amd64":
+		return "x64"
+	default:
+		return arch
+	}
+}
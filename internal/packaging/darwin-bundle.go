@@ -1,6 +1,7 @@
 package packaging
 
 import (
+	"context"
 	"fmt"
 	"image"
 	"os"
@@ -17,7 +18,7 @@ var DarwinBundleTask = &packagingTask{
 	},
 	executableFiles:             []string{},
 	flutterBuildOutputDirectory: "{{.applicationName}} {{.version}}.app/Contents/MacOS",
-	packagingFunction: func(tmpPath, applicationName, packageName, executableName, version, release string) (string, error) {
+	packagingFunction: func(ctx context.Context, tmpPath, applicationName, packageName, executableName, version, release, arch string, reproducible bool) (string, error) {
 		outputFileName := fmt.Sprintf("%s %s.app", applicationName, version)
 		err := os.MkdirAll(filepath.Join(tmpPath, outputFileName, "Contents", "Resources"), 0755)
 		if err != nil {
@@ -48,6 +49,7 @@ var DarwinBundleTask = &packagingTask{
 
 		return outputFileName, nil
 	},
+	reproducible: true,
 	requiredTools: map[string]map[string]string{
 		"linux":   {},
 		"darwin":  {},
@@ -1,6 +1,7 @@
 package packaging
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -14,9 +15,9 @@ var DarwinDmgTask = &packagingTask{
 	dependsOn: map[*packagingTask]string{
 		DarwinBundleTask: "dmgdir",
 	},
-	packagingFunction: func(tmpPath, applicationName, packageName, executableName, version, release string) (string, error) {
+	packagingFunction: func(ctx context.Context, tmpPath, applicationName, packageName, executableName, version, release, arch string, reproducible bool) (string, error) {
 		outputFileName := fmt.Sprintf("%s %s.dmg", applicationName, version)
-		cmdLn := exec.Command("ln", "-sf", "/Applications", "dmgdir/Applications")
+		cmdLn := exec.CommandContext(ctx, "ln", "-sf", "/Applications", "dmgdir/Applications")
 		cmdLn.Dir = tmpPath
 		cmdLn.Stdout = os.Stdout
 		cmdLn.Stderr = os.Stderr
@@ -34,9 +35,9 @@ var DarwinDmgTask = &packagingTask{
 		var cmdCreateBundle *exec.Cmd
 		switch os := runtime.GOOS; os {
 		case "darwin":
-			cmdCreateBundle = exec.Command("hdiutil", "create", "-volname", packageName, "-srcfolder", "dmgdir", "-ov", "-format", "UDBZ", outputFileName)
+			cmdCreateBundle = exec.CommandContext(ctx, "hdiutil", "create", "-volname", packageName, "-srcfolder", "dmgdir", "-ov", "-format", "UDBZ", outputFileName)
 		case "linux":
-			cmdCreateBundle = exec.Command("mkisofs", "-V", packageName, "-D", "-R", "-apple", "-no-pad", "-o", outputFileName, "dmgdir")
+			cmdCreateBundle = exec.CommandContext(ctx, "mkisofs", "-V", packageName, "-D", "-R", "-apple", "-no-pad", "-o", outputFileName, "dmgdir")
 		}
 		cmdCreateBundle.Dir = tmpPath
 		cmdCreateBundle.Stdout = os.Stdout
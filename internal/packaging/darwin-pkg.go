@@ -1,6 +1,7 @@
 package packaging
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -20,7 +21,7 @@ var DarwinPkgTask = &packagingTask{
 		"darwin-pkg/PackageInfo.tmpl":  "flat/base.pkg/PackageInfo.tmpl",
 		"darwin-pkg/Distribution.tmpl": "flat/Distribution.tmpl",
 	},
-	packagingFunction: func(tmpPath, applicationName, packageName, executableName, version, release string) (string, error) {
+	packagingFunction: func(ctx context.Context, tmpPath, applicationName, packageName, executableName, version, release, arch string, reproducible bool) (string, error) {
 		outputFileName := fmt.Sprintf("%s %s.pkg", applicationName, version)
 
 		payload, err := os.OpenFile(filepath.Join(tmpPath, "flat", "base.pkg", "Payload"), os.O_RDWR|os.O_CREATE, 0755)
@@ -35,11 +36,11 @@ var DarwinPkgTask = &packagingTask{
 			return "", err
 		}
 
-		cmdFind := exec.Command("find", ".")
+		cmdFind := exec.CommandContext(ctx, "find", ".")
 		cmdFind.Dir = filepath.Join(tmpPath, "flat", "root")
-		cmdCpio := exec.Command("cpio", "-o", "--format", "odc", "--owner", "0:80")
+		cmdCpio := exec.CommandContext(ctx, "cpio", "-o", "--format", "odc", "--owner", "0:80")
 		cmdCpio.Dir = filepath.Join(tmpPath, "flat", "root")
-		cmdGzip := exec.Command("gzip", "-c")
+		cmdGzip := exec.CommandContext(ctx, "gzip", "-c")
 
 		// Pipes like this: find | cpio | gzip > Payload
 		cmdCpio.Stdin, err = cmdFind.StderrPipe()
@@ -80,9 +81,9 @@ var DarwinPkgTask = &packagingTask{
 		var cmdMkbom *exec.Cmd
 		switch os := runtime.GOOS; os {
 		case "darwin":
-			cmdMkbom = exec.Command("mkbom", filepath.Join("flat", "root"), filepath.Join("flat", "base.pkg", "Payload"))
+			cmdMkbom = exec.CommandContext(ctx, "mkbom", filepath.Join("flat", "root"), filepath.Join("flat", "base.pkg", "Payload"))
 		case "linux":
-			cmdMkbom = exec.Command("mkbom", "-u", "0", "-g", "80", filepath.Join("flat", "root"), filepath.Join("flat", "base.pkg", "Payload"))
+			cmdMkbom = exec.CommandContext(ctx, "mkbom", "-u", "0", "-g", "80", filepath.Join("flat", "root"), filepath.Join("flat", "base.pkg", "Payload"))
 		}
 		cmdMkbom.Dir = tmpPath
 		cmdMkbom.Stdout = os.Stdout
@@ -105,7 +106,7 @@ var DarwinPkgTask = &packagingTask{
 			return "", errors.Wrap(err, "failed to iterate over ")
 		}
 
-		cmdXar := exec.Command("xar", append([]string{"--compression", "none", "-cf", filepath.Join("..", outputFileName)}, files...)...)
+		cmdXar := exec.CommandContext(ctx, "xar", append([]string{"--compression", "none", "-cf", filepath.Join("..", outputFileName)}, files...)...)
 		cmdXar.Dir = filepath.Join(tmpPath, "flat")
 		cmdXar.Stdout = os.Stdout
 		cmdXar.Stderr = os.Stderr
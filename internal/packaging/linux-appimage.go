@@ -1,6 +1,7 @@
 package packaging
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -8,8 +9,7 @@ import (
 	"strings"
 
 	copy "github.com/otiai10/copy"
-
-	"github.com/go-flutter-desktop/hover/internal/log"
+	"github.com/pkg/errors"
 )
 
 // LinuxAppImageTask packaging for linux as AppImage
@@ -26,40 +26,37 @@ var LinuxAppImageTask = &packagingTask{
 	},
 	linuxDesktopFileIconPath:    "{{.packageName}}",
 	flutterBuildOutputDirectory: "build",
-	packagingFunction: func(tmpPath, applicationName, packageName, executableName, version, release string) (string, error) {
+	packagingFunction: func(ctx context.Context, tmpPath, applicationName, packageName, executableName, version, release, arch string, reproducible bool) (string, error) {
 		sourceIconPath := filepath.Join(tmpPath, "build", "assets", "icon.png")
 		iconDir := filepath.Join(tmpPath, "usr", "share", "icons", "hicolor", "256x256", "apps")
 		if _, err := os.Stat(iconDir); os.IsNotExist(err) {
 			err = os.MkdirAll(iconDir, 0755)
 			if err != nil {
-				log.Errorf("Failed to create icon dir: %v", err)
-				os.Exit(1)
+				return "", errors.Wrap(err, "failed to create icon dir")
 			}
 		}
 		err := copy.Copy(sourceIconPath, filepath.Join(tmpPath, fmt.Sprintf("%s.png", packageName)))
 		if err != nil {
-			log.Errorf("Failed to copy icon root dir: %v", err)
-			os.Exit(1)
+			return "", errors.Wrap(err, "failed to copy icon root dir")
 		}
 		err = copy.Copy(sourceIconPath, filepath.Join(iconDir, fmt.Sprintf("%s.png", packageName)))
 		if err != nil {
-			log.Errorf("Failed to copy icon dir: %v", err)
-			os.Exit(1)
+			return "", errors.Wrap(err, "failed to copy icon dir")
 		}
-		cmdAppImageTool := exec.Command("appimagetool", ".")
+		cmdAppImageTool := exec.CommandContext(ctx, "appimagetool", ".")
 		cmdAppImageTool.Dir = tmpPath
 		cmdAppImageTool.Stdout = os.Stdout
 		cmdAppImageTool.Stderr = os.Stderr
 		cmdAppImageTool.Env = append(
 			os.Environ(),
-			"ARCH=x86_64",
+			"ARCH="+rpmArch(arch),
 			fmt.Sprintf("VERSION=%s", version),
 		)
 		err = cmdAppImageTool.Run()
 		if err != nil {
 			return "", err
 		}
-		return fmt.Sprintf("%s-%s-x86_64.AppImage", strings.ReplaceAll(applicationName, " ", "_"), version), nil
+		return fmt.Sprintf("%s-%s-%s.AppImage", strings.ReplaceAll(applicationName, " ", "_"), version, rpmArch(arch)), nil
 	},
 	requiredTools: map[string]map[string]string{
 		"linux": {
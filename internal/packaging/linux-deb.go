@@ -1,6 +1,7 @@
 package packaging
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -21,9 +22,15 @@ var LinuxDebTask = &packagingTask{
 	linuxDesktopFileExecutablePath: "/usr/lib/{{.packageName}}/{{.executableName}}",
 	linuxDesktopFileIconPath:       "/usr/lib/{{.packageName}}/assets/icon.png",
 	flutterBuildOutputDirectory:    "usr/lib/{{.packageName}}",
-	packagingFunction: func(tmpPath, applicationName, packageName, executableName, version, release string) (string, error) {
-		outputFileName := fmt.Sprintf("%s_%s_amd64.deb", packageName, version)
-		cmdDpkgDeb := exec.Command("dpkg-deb", "--build", ".", outputFileName)
+	packagingFunction: func(ctx context.Context, tmpPath, applicationName, packageName, executableName, version, release, arch string, reproducible bool) (string, error) {
+		outputFileName := fmt.Sprintf("%s_%s_%s.deb", packageName, version, debArch(arch))
+		dpkgDebArgs := []string{"--build", ".", outputFileName}
+		if reproducible {
+			// dpkg-deb uses SOURCE_DATE_EPOCH for the timestamps, the files
+			// must also not belong to the user building the package
+			dpkgDebArgs = append([]string{"--root-owner-group"}, dpkgDebArgs...)
+		}
+		cmdDpkgDeb := exec.CommandContext(ctx, "dpkg-deb", dpkgDebArgs...)
 		cmdDpkgDeb.Dir = tmpPath
 		cmdDpkgDeb.Stdout = os.Stdout
 		cmdDpkgDeb.Stderr = os.Stderr
@@ -33,6 +40,7 @@ var LinuxDebTask = &packagingTask{
 		}
 		return outputFileName, nil
 	},
+	reproducible: true,
 	requiredTools: map[string]map[string]string{
 		"linux": {
 			"dpkg-deb": "You need to be on Debian, Ubuntu or another distro that uses apt/dpkg as package manager to use this. Installing dpkg on other distros is hard and dangerous.",
@@ -0,0 +1,68 @@
+package packaging
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/pkg/errors"
+)
+
+// LinuxPkgTask packaging for linux as pacman pkg
+var LinuxPkgTask = &packagingTask{
+	packagingFormatName: "linux-pkg",
+	templateFiles: map[string]string{
+		"linux-pkg/PKGBUILD.tmpl": "PKGBUILD.tmpl",
+		"linux/bin.tmpl":          "src/usr/bin/{{.executableName}}.tmpl",
+		"linux/app.desktop.tmpl":  "src/usr/share/applications/{{.executableName}}.desktop.tmpl",
+	},
+	executableFiles: []string{
+		"src/usr/bin/{{.executableName}}",
+		"src/usr/share/applications/{{.executableName}}.desktop",
+	},
+	linuxDesktopFileExecutablePath: "/usr/lib/{{.packageName}}/{{.executableName}}",
+	linuxDesktopFileIconPath:       "/usr/lib/{{.packageName}}/assets/icon.png",
+	flutterBuildOutputDirectory:    "src/usr/lib/{{.packageName}}",
+	packagingFunction: func(ctx context.Context, tmpPath, applicationName, packageName, executableName, version, release, arch string, reproducible bool) (string, error) {
+		extension := ".pkg.tar.xz"
+		cmdMakepkg := exec.CommandContext(ctx, "makepkg")
+		cmdMakepkg.Dir = tmpPath
+		cmdMakepkg.Stdout = os.Stdout
+		cmdMakepkg.Stderr = os.Stderr
+		env, err := makepkgEnv(extension, arch, reproducible)
+		if err != nil {
+			return "", err
+		}
+		cmdMakepkg.Env = append(os.Environ(), env...)
+		err = cmdMakepkg.Run()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s-%s-%s-%s%s", packageName, version, release, rpmArch(arch), extension), nil
+	},
+	reproducible: true,
+	requiredTools: map[string]map[string]string{
+		"linux": {
+			"makepkg": "You need to be on Arch Linux or another distro that uses pacman as package manager to use this. Installing makepkg on other distros is hard and dangerous.",
+		},
+	},
+}
+
+// makepkgEnv returns the environment variables makepkg is run with. makepkg
+// uses SOURCE_DATE_EPOCH for the build date and the times of the packaged
+// files, it is passed explicitly for reproducible builds.
+func makepkgEnv(extension, arch string, reproducible bool) ([]string, error) {
+	env := []string{fmt.Sprintf("PKGEXT=%s", extension), fmt.Sprintf("CARCH=%s", rpmArch(arch))}
+	if reproducible {
+		epoch, ok, err := sourceDateEpoch()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.New("SOURCE_DATE_EPOCH must be set to build a reproducible pacman package")
+		}
+		env = append(env, fmt.Sprintf("SOURCE_DATE_EPOCH=%d", epoch.Unix()))
+	}
+	return env, nil
+}
@@ -0,0 +1,42 @@
+package packaging
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMakepkgEnv(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	env, err := makepkgEnv(".pkg.tar.xz", "amd64", false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"PKGEXT=.pkg.tar.xz", "CARCH=x86_64"}, env)
+
+	env, err = makepkgEnv(".pkg.tar.xz", "amd64", true)
+	require.NoError(t, err)
+	require.Equal(t, []string{"PKGEXT=.pkg.tar.xz", "CARCH=x86_64", "SOURCE_DATE_EPOCH=1700000000"}, env)
+
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+	_, err = makepkgEnv(".pkg.tar.xz", "amd64", true)
+	require.Error(t, err)
+}
+
+func TestReproducibleBuildDirectory(t *testing.T) {
+	require.True(t, LinuxPkgTask.reproducible)
+
+	first, err := getTemporaryBuildDirectory("app", "linux-pkg", true)
+	require.NoError(t, err)
+	defer os.RemoveAll(first)
+	err = ioutil.WriteFile(filepath.Join(first, "PKGBUILD"), []byte("stale"), 0644)
+	require.NoError(t, err)
+
+	second, err := getTemporaryBuildDirectory("app", "linux-pkg", true)
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+	files, err := ioutil.ReadDir(second)
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
@@ -0,0 +1,51 @@
+package packaging
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// LinuxRpmTask packaging for linux as rpm
+var LinuxRpmTask = &packagingTask{
+	packagingFormatName: "linux-rpm",
+	templateFiles: map[string]string{
+		"linux-rpm/app.spec.tmpl": "SPECS/{{.packageName}}.spec.tmpl",
+		"linux/bin.tmpl":          "BUILDROOT/{{.packageName}}-{{.version}}-{{.release}}.{{.rpmArch}}/usr/bin/{{.executableName}}.tmpl",
+		"linux/app.desktop.tmpl":  "BUILDROOT/{{.packageName}}-{{.version}}-{{.release}}.{{.rpmArch}}/usr/share/applications/{{.executableName}}.desktop.tmpl",
+	},
+	executableFiles: []string{
+		"BUILDROOT/{{.packageName}}-{{.version}}-{{.release}}.{{.rpmArch}}/usr/bin/{{.executableName}}",
+		"BUILDROOT/{{.packageName}}-{{.version}}-{{.release}}.{{.rpmArch}}/usr/share/applications/{{.executableName}}.desktop",
+	},
+	linuxDesktopFileExecutablePath: "/usr/lib/{{.packageName}}/{{.executableName}}",
+	linuxDesktopFileIconPath:       "/usr/lib/{{.packageName}}/assets/icon.png",
+	flutterBuildOutputDirectory:    "BUILD/{{.packageName}}-{{.version}}-{{.release}}.{{.rpmArch}}/usr/lib/{{.packageName}}",
+	packagingFunction: func(ctx context.Context, tmpPath, applicationName, packageName, executableName, version, release, arch string, reproducible bool) (string, error) {
+		rpmbuildArgs := []string{"--define", fmt.Sprintf("_topdir %s", tmpPath), "--define", "_unpackaged_files_terminate_build 0"}
+		if reproducible {
+			rpmbuildArgs = append(rpmbuildArgs,
+				"--define", "use_source_date_epoch_as_buildtime 1",
+				"--define", "clamp_mtime_to_source_date_epoch 1",
+				"--define", "_buildhost reproducible",
+			)
+		}
+		rpmbuildArgs = append(rpmbuildArgs, "--target", rpmArch(arch), "-ba", fmt.Sprintf("./SPECS/%s.spec", packageName))
+		cmdRpmbuild := exec.CommandContext(ctx, "rpmbuild", rpmbuildArgs...)
+		cmdRpmbuild.Dir = tmpPath
+		cmdRpmbuild.Stdout = os.Stdout
+		cmdRpmbuild.Stderr = os.Stderr
+		err := cmdRpmbuild.Run()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("RPMS/%s/%s-%s-%s.%s.rpm", rpmArch(arch), packageName, version, release, rpmArch(arch)), nil
+	},
+	reproducible: true,
+	requiredTools: map[string]map[string]string{
+		"linux": {
+			"rpmbuild": "You need to be on Red Hat Linux or another distro that uses rpm as package manager to use this. Installing rpmbuild on other distros is hard and dangerous.",
+		},
+	},
+}
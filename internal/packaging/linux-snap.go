@@ -1,6 +1,7 @@
 package packaging
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -16,8 +17,8 @@ var LinuxSnapTask = &packagingTask{
 	linuxDesktopFileExecutablePath: "/{{.executableName}}",
 	linuxDesktopFileIconPath:       "/icon.png",
 	flutterBuildOutputDirectory:    "build",
-	packagingFunction: func(tmpPath, applicationName, packageName, executableName, version, release string) (string, error) {
-		cmdSnapcraft := exec.Command("snapcraft")
+	packagingFunction: func(ctx context.Context, tmpPath, applicationName, packageName, executableName, version, release, arch string, reproducible bool) (string, error) {
+		cmdSnapcraft := exec.CommandContext(ctx, "snapcraft")
 		cmdSnapcraft.Dir = tmpPath
 		cmdSnapcraft.Stdout = os.Stdout
 		cmdSnapcraft.Stderr = os.Stderr
@@ -25,7 +26,7 @@ var LinuxSnapTask = &packagingTask{
 		if err != nil {
 			return "", err
 		}
-		return fmt.Sprintf("%s_%s_amd64.snap", packageName, version), nil
+		return fmt.Sprintf("%s_%s_%s.snap", packageName, version, debArch(arch)), nil
 	},
 	requiredTools: map[string]map[string]string{
 		"linux": {
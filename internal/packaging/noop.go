@@ -0,0 +1,21 @@
+package packaging
+
+import (
+	"context"
+
+	"github.com/go-flutter-desktop/hover/internal/build"
+)
+
+type noopTask struct{}
+
+var NoopTask Task = &noopTask{}
+
+func (_ *noopTask) Name() string             { return "" }
+func (_ *noopTask) Init() error              { return nil }
+func (_ *noopTask) IsInitialized() bool      { return true }
+func (_ *noopTask) AssertInitialized() error { return nil }
+func (_ *noopTask) Pack(context.Context, string, string, build.Mode, bool) (string, error) {
+	return "", nil
+}
+func (_ *noopTask) IsSupported() bool      { return true }
+func (_ *noopTask) AssertSupported() error { return nil }
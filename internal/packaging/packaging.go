@@ -0,0 +1,390 @@
+package packaging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/go-flutter-desktop/hover/internal/pubspec"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+
+	"github.com/otiai10/copy"
+	"github.com/pkg/errors"
+
+	"github.com/go-flutter-desktop/hover/internal/build"
+	"github.com/go-flutter-desktop/hover/internal/config"
+	"github.com/go-flutter-desktop/hover/internal/fileutils"
+	"github.com/go-flutter-desktop/hover/internal/log"
+)
+
+var packagingPath = filepath.Join(build.BuildPath, "packaging")
+
+func packagingFormatPath(packagingFormat string) (string, error) {
+	directoryPath, err := filepath.Abs(filepath.Join(packagingPath, packagingFormat))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to resolve absolute path for %s directory", packagingFormat)
+	}
+	return directoryPath, nil
+}
+
+func createPackagingFormatDirectory(packagingFormat string) (string, error) {
+	directoryPath, err := packagingFormatPath(packagingFormat)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(directoryPath); !os.IsNotExist(err) {
+		return "", errors.Errorf("a file or directory named `%s` already exists. Cannot continue packaging init for %s", packagingFormat, packagingFormat)
+	}
+	err = os.MkdirAll(directoryPath, 0775)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create %s directory %s", packagingFormat, directoryPath)
+	}
+	return directoryPath, nil
+}
+
+func getTemporaryBuildDirectory(projectName string, packagingFormat string, reproducible bool) (string, error) {
+	if reproducible {
+		// some packaging tools record the directory they are run in, like
+		// makepkg in the .BUILDINFO file, it must not change between builds
+		tmpPath := filepath.Join(os.TempDir(), "hover-build-"+projectName+"-"+packagingFormat)
+		err := os.RemoveAll(tmpPath)
+		if err != nil {
+			return "", errors.Wrap(err, "couldn't clean temporary build directory")
+		}
+		err = os.Mkdir(tmpPath, 0755)
+		if err != nil {
+			return "", errors.Wrap(err, "couldn't create temporary build directory")
+		}
+		return tmpPath, nil
+	}
+	tmpPath, err := ioutil.TempDir("", "hover-build-"+projectName+"-"+packagingFormat)
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't get temporary build directory")
+	}
+	return tmpPath, nil
+}
+
+type packagingTask struct {
+	packagingFormatName            string                                                                                                                                             // Name of the packaging format: OS-TYPE
+	dependsOn                      map[*packagingTask]string                                                                                                                          // Packaging tasks this task depends on
+	templateFiles                  map[string]string                                                                                                                                  // Template files to copy over on init
+	executableFiles                []string                                                                                                                                           // Files that should be executable
+	linuxDesktopFileExecutablePath string                                                                                                                                             // Path of the executable for linux .desktop file (only set on linux)
+	linuxDesktopFileIconPath       string                                                                                                                                             // Path of the icon for linux .desktop file (only set on linux)
+	generateBuildFiles             func(packageName, path string) error                                                                                                               // Generate dynamic build files. Operates in the temporary directory
+	generateInitFiles              func(packageName, path string) error                                                                                                               // Generate dynamic init files
+	extraTemplateData              func(packageName, path string) (map[string]string, error)                                                                                          // Update the template data on build. This is used for inserting values that are generated on init
+	flutterBuildOutputDirectory    string                                                                                                                                             // Path to copy the build output of the app to. Operates in the temporary directory
+	packagingFunction              func(ctx context.Context, tmpPath, applicationName, packageName, executableName, version, release, arch string, reproducible bool) (string, error) // Function that actually packages the app. Needs to check for OS specific tools etc. . Returns the path of the packaged file, reproducible is set by reproducible builds
+	reproducible                   bool                                                                                                                                               // Set to true when the packages are identical for identical files
+	skipAssertInitialized          bool                                                                                                                                               // Set to true when a task doesn't need to be initialized.
+	requiredTools                  map[string]map[string]string                                                                                                                       // Map of list of tools required to package per OS
+}
+
+func (t *packagingTask) AssertSupported() error {
+	if !t.IsSupported() {
+		return errors.Errorf("packaging %s is not supported", t.packagingFormatName)
+	}
+	return nil
+}
+
+func (t *packagingTask) IsSupported() bool {
+	for task := range t.dependsOn {
+		if !task.IsSupported() {
+			return false
+		}
+	}
+	if _, osIsSupported := t.requiredTools[runtime.GOOS]; !osIsSupported {
+		log.Errorf("Packaging %s is not supported on %s", t.packagingFormatName, runtime.GOOS)
+		log.Errorf("To still package %s on %s you need to run hover with the `--docker` flag.", t.packagingFormatName, runtime.GOOS)
+		return false
+	}
+	var unavailableTools []string
+	for tool := range t.requiredTools[runtime.GOOS] {
+		_, err := exec.LookPath(tool)
+		if err != nil {
+			unavailableTools = append(unavailableTools, tool)
+		}
+	}
+	if len(unavailableTools) > 0 {
+		log.Errorf("To package %s these tools are required: %s", t.packagingFormatName, strings.Join(unavailableTools, ","))
+		for _, tool := range unavailableTools {
+			text := t.requiredTools[runtime.GOOS][tool]
+			if len(text) > 0 {
+				log.Infof("%s", text)
+			}
+		}
+		log.Infof("To still package %s without the required tools installed you need to run hover with the `--docker` flag.", t.packagingFormatName)
+		return false
+	}
+	return true
+}
+
+func (t *packagingTask) Name() string {
+	return t.packagingFormatName
+}
+
+func (t *packagingTask) Init() error {
+	return t.init(false)
+}
+
+func (t *packagingTask) init(ignoreAlreadyExists bool) error {
+	for task := range t.dependsOn {
+		err := task.init(true)
+		if err != nil {
+			return err
+		}
+	}
+	if t.IsInitialized() {
+		if !ignoreAlreadyExists {
+			return errors.Errorf("%s is already initialized for packaging", t.packagingFormatName)
+		}
+		return nil
+	}
+	dir, err := createPackagingFormatDirectory(t.packagingFormatName)
+	if err != nil {
+		return err
+	}
+	for sourceFile, destinationFile := range t.templateFiles {
+		destinationFile = filepath.Join(dir, destinationFile)
+		err := os.MkdirAll(filepath.Dir(destinationFile), 0775)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create directory %s", filepath.Dir(destinationFile))
+		}
+		err = fileutils.CopyAsset(fmt.Sprintf("packaging/%s", sourceFile), destinationFile)
+		if err != nil {
+			return err
+		}
+	}
+	if t.generateInitFiles != nil {
+		log.Infof("Generating dynamic init files")
+		pubSpec, err := pubspec.LoadPubSpec()
+		if err != nil {
+			return err
+		}
+		err = t.generateInitFiles(config.GetConfig().GetPackageName(pubSpec.Name), dir)
+		if err != nil {
+			return err
+		}
+	}
+	log.Infof("go/packaging/%s has been created. You can modify the configuration files and add it to git.", t.packagingFormatName)
+	log.Infof("You now can package the %s using `%s`", strings.Split(t.packagingFormatName, "-")[0], log.Au().Magenta("hover build "+t.packagingFormatName))
+	return nil
+}
+
+func (t *packagingTask) Pack(ctx context.Context, fullVersion string, arch string, mode build.Mode, reproducible bool) (string, error) {
+	pubSpec, err := pubspec.LoadPubSpec()
+	if err != nil {
+		return "", err
+	}
+	projectName := pubSpec.Name
+	version := strings.Split(fullVersion, "+")[0]
+	var release string
+	if strings.Contains(fullVersion, "+") {
+		release = strings.Split(fullVersion, "+")[1]
+	} else {
+		release = strings.ReplaceAll(fullVersion, ".", "")
+	}
+	description := pubSpec.GetDescription()
+	author := pubSpec.GetAuthor()
+	organizationName := config.GetConfig().GetOrganizationName()
+	applicationName := config.GetConfig().GetApplicationName(projectName)
+	executableName := config.GetConfig().GetExecutableName(projectName)
+	packageName := config.GetConfig().GetPackageName(projectName)
+	license := config.GetConfig().GetLicense()
+	templateData := map[string]string{
+		"projectName":      projectName,
+		"version":          version,
+		"release":          release,
+		"description":      description,
+		"organizationName": organizationName,
+		"author":           author,
+		"applicationName":  applicationName,
+		"executableName":   executableName,
+		"packageName":      packageName,
+		"license":          license,
+		"arch":             arch,
+		"debArch":          debArch(arch),
+		"rpmArch":          rpmArch(arch),
+		"msiArch":          msiArch(arch),
+	}
+	templateData["iconPath"], err = executeStringTemplate(t.linuxDesktopFileIconPath, templateData)
+	if err != nil {
+		return "", err
+	}
+	templateData["executablePath"], err = executeStringTemplate(t.linuxDesktopFileExecutablePath, templateData)
+	if err != nil {
+		return "", err
+	}
+	return t.pack(ctx, templateData, packageName, projectName, applicationName, executableName, version, release, arch, mode, reproducible)
+}
+
+// pack packages the app and returns the path of the packaged file.
+func (t *packagingTask) pack(ctx context.Context, templateData map[string]string, packageName, projectName, applicationName, executableName, version, release, arch string, mode build.Mode, reproducible bool) (outputFilePath string, err error) {
+	formatPath, err := packagingFormatPath(t.packagingFormatName)
+	if err != nil {
+		return "", err
+	}
+	if t.extraTemplateData != nil {
+		extraTemplateData, err := t.extraTemplateData(packageName, formatPath)
+		if err != nil {
+			return "", err
+		}
+		for key, value := range extraTemplateData {
+			templateData[key] = value
+		}
+	}
+	for task := range t.dependsOn {
+		_, err := task.pack(ctx, templateData, packageName, projectName, applicationName, executableName, version, release, arch, mode, reproducible)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to package %s", task.packagingFormatName)
+		}
+	}
+	tmpPath, err := getTemporaryBuildDirectory(projectName, t.packagingFormatName, reproducible)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		removeErr := os.RemoveAll(tmpPath)
+		if removeErr != nil && err == nil {
+			err = errors.Wrap(removeErr, "could not remove temporary build directory")
+		}
+	}()
+	log.Infof("Packaging %s in %s", strings.Split(t.packagingFormatName, "-")[1], tmpPath)
+
+	if t.flutterBuildOutputDirectory != "" {
+		buildOutputDirectory, err := build.OutputDirectoryPath(strings.Split(t.packagingFormatName, "-")[0], arch, mode)
+		if err != nil {
+			return "", err
+		}
+		destination, err := executeStringTemplate(filepath.Join(tmpPath, t.flutterBuildOutputDirectory), templateData)
+		if err != nil {
+			return "", err
+		}
+		err = copy.Copy(buildOutputDirectory, destination)
+		if err != nil {
+			return "", errors.Wrap(err, "could not copy build folder")
+		}
+	}
+	for task, destination := range t.dependsOn {
+		taskOutputDirectory, err := build.OutputDirectoryPath(task.packagingFormatName, arch, mode)
+		if err != nil {
+			return "", err
+		}
+		err = copy.Copy(taskOutputDirectory, filepath.Join(tmpPath, destination))
+		if err != nil {
+			return "", errors.Wrapf(err, "could not copy build folder of %s", task.packagingFormatName)
+		}
+	}
+	err = fileutils.CopyTemplateDir(formatPath, filepath.Join(tmpPath), templateData)
+	if err != nil {
+		return "", err
+	}
+	if t.generateBuildFiles != nil {
+		log.Infof("Generating dynamic build files")
+		err = t.generateBuildFiles(packageName, tmpPath)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	for _, file := range t.executableFiles {
+		executableFile, err := executeStringTemplate(filepath.Join(tmpPath, file), templateData)
+		if err != nil {
+			return "", err
+		}
+		err = os.Chmod(executableFile, 0777)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to change file permissions for %s file", file)
+		}
+	}
+
+	if reproducible {
+		if !t.reproducible {
+			log.Warnf("The %s packages aren't reproducible, only the files they contain are", t.packagingFormatName)
+		}
+		epoch, ok, err := sourceDateEpoch()
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "", errors.New("reproducible packages need a timestamp, set SOURCE_DATE_EPOCH")
+		}
+		err = normalizeTree(tmpPath, epoch)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	outputDirectory, err := build.OutputDirectoryPath(t.packagingFormatName, arch, mode)
+	if err != nil {
+		return "", err
+	}
+	err = os.RemoveAll(outputDirectory)
+	log.Printf("Cleaning the build directory")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to clean output directory %s", outputDirectory)
+	}
+
+	relativeOutputFilePath, err := t.packagingFunction(ctx, tmpPath, applicationName, packageName, executableName, version, release, arch, reproducible)
+	if err != nil {
+		log.Warnf("Packaging is very experimental and has mostly been tested on Linux.")
+		log.Infof("Please open an issue at https://github.com/go-flutter-desktop/go-flutter/issues/new?template=BUG.md")
+		log.Infof("with the log and a reproducible example if possible. You may also zip your app code")
+		log.Infof("if you are comfortable with it (closed source etc.) and attach it to the issue.")
+		return "", err
+	}
+	// the output directory has been removed above, get it created again
+	outputDirectory, err = build.OutputDirectoryPath(t.packagingFormatName, arch, mode)
+	if err != nil {
+		return "", err
+	}
+	outputFileName := filepath.Base(relativeOutputFilePath)
+	outputFilePath = filepath.Join(outputDirectory, outputFileName)
+	err = copy.Copy(filepath.Join(tmpPath, relativeOutputFilePath), outputFilePath)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not move %s file", outputFileName)
+	}
+	err = os.Chmod(outputFilePath, 0755)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not change file permissions for %s", outputFileName)
+	}
+	return outputFilePath, nil
+}
+
+func (t *packagingTask) AssertInitialized() error {
+	if t.skipAssertInitialized {
+		return nil
+	}
+	if !t.IsInitialized() {
+		return errors.Errorf("%s is not initialized for packaging. Please run `hover init-packaging %s` first", t.packagingFormatName, t.packagingFormatName)
+	}
+	return nil
+}
+
+func (t *packagingTask) IsInitialized() bool {
+	directoryPath, err := packagingFormatPath(t.packagingFormatName)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(directoryPath)
+	return !os.IsNotExist(err)
+}
+
+func executeStringTemplate(t string, data map[string]string) (string, error) {
+	tmplFile, err := template.New("").Option("missingkey=error").Parse(t)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse template string")
+	}
+	var tmplBytes bytes.Buffer
+	err = tmplFile.Execute(&tmplBytes, data)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to execute template string")
+	}
+	return tmplBytes.String(), nil
+}
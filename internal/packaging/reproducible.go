@@ -0,0 +1,65 @@
+package packaging
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// sourceDateEpoch returns the timestamp of reproducible builds, set by the
+// SOURCE_DATE_EPOCH environment variable. ok is false when it isn't set.
+func sourceDateEpoch() (epoch time.Time, ok bool, err error) {
+	value := os.Getenv("SOURCE_DATE_EPOCH")
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false, errors.Errorf("SOURCE_DATE_EPOCH must be a number of seconds, not %q", value)
+	}
+	return time.Unix(seconds, 0), true, nil
+}
+
+// normalizeTree sets the modification time of the files in the directory at
+// path to epoch, and their permissions to 0755 for the directories and the
+// executables and 0644 for the other files. The packages built from the
+// directory then don't depend on when and by whom the files were copied.
+func normalizeTree(path string, epoch time.Time) error {
+	var directories []string
+	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return nil
+		}
+		mode := os.FileMode(0644)
+		if info.IsDir() || info.Mode()&0111 != 0 {
+			mode = 0755
+		}
+		err = os.Chmod(p, mode)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			// the times of a directory change when its files are modified,
+			// they are set once the walk is done
+			directories = append(directories, p)
+			return nil
+		}
+		return os.Chtimes(p, epoch, epoch)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to normalize the packaging files")
+	}
+	for _, directory := range directories {
+		err = os.Chtimes(directory, epoch, epoch)
+		if err != nil {
+			return errors.Wrap(err, "failed to normalize the packaging files")
+		}
+	}
+	return nil
+}
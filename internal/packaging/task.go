@@ -0,0 +1,19 @@
+package packaging
+
+import (
+	"context"
+
+	"github.com/go-flutter-desktop/hover/internal/build"
+)
+
+// Task contains all configuration options for a given packaging method.
+// TODO: Rename to something that suits it more? Mabe Executor?
+type Task interface {
+	Name() string
+	Init() error
+	IsInitialized() bool
+	AssertInitialized() error
+	Pack(ctx context.Context, buildVersion string, arch string, mode build.Mode, reproducible bool) (string, error)
+	IsSupported() bool
+	AssertSupported() error
+}
@@ -1,6 +1,7 @@
 package packaging
 
 import (
+	"context"
 	"crypto/sha1"
 	"encoding/hex"
 	"fmt"
@@ -14,7 +15,9 @@ import (
 
 	ico "github.com/Kodeworks/golang-image-ico"
 	"github.com/google/uuid"
+	"github.com/pkg/errors"
 
+	"github.com/go-flutter-desktop/hover/internal/build"
 	"github.com/go-flutter-desktop/hover/internal/log"
 )
 
@@ -29,7 +32,7 @@ var WindowsMsiTask = &packagingTask{
 		"windows-msi/app.wxs.tmpl": "{{.packageName}}.wxs.tmpl",
 	},
 	flutterBuildOutputDirectory: "build",
-	packagingFunction: func(tmpPath, applicationName, packageName, executableName, version, release string) (string, error) {
+	packagingFunction: func(ctx context.Context, tmpPath, applicationName, packageName, executableName, version, release, arch string, reproducible bool) (string, error) {
 		outputFileName := fmt.Sprintf("%s %s.msi", applicationName, version)
 		iconPngFile, err := os.Open(filepath.Join(tmpPath, "build", "assets", "icon.png"))
 		if err != nil {
@@ -57,9 +60,16 @@ var WindowsMsiTask = &packagingTask{
 		if err != nil {
 			return "", err
 		}
+		// The msi architecture is only set for non-default architectures to keep
+		// the existing x86 installer layout for amd64.
+		var candleArgs, wixlArgs []string
+		if arch != build.DefaultArch {
+			candleArgs = []string{"-arch", msiArch(arch)}
+			wixlArgs = []string{"--arch", msiArch(arch)}
+		}
 		switch runtime.GOOS {
 		case "windows":
-			cmdCandle := exec.Command("candle", fmt.Sprintf("%s.wxs", packageName))
+			cmdCandle := exec.CommandContext(ctx, "candle", append(candleArgs, fmt.Sprintf("%s.wxs", packageName))...)
 			cmdCandle.Dir = tmpPath
 			cmdCandle.Stdout = os.Stdout
 			cmdCandle.Stderr = os.Stderr
@@ -67,7 +77,7 @@ var WindowsMsiTask = &packagingTask{
 			if err != nil {
 				return "", err
 			}
-			cmdLight := exec.Command("light", fmt.Sprintf("%s.wixobj", packageName), "-sval")
+			cmdLight := exec.CommandContext(ctx, "light", fmt.Sprintf("%s.wixobj", packageName), "-sval")
 			cmdLight.Dir = tmpPath
 			cmdLight.Stdout = os.Stdout
 			cmdLight.Stderr = os.Stderr
@@ -80,7 +90,7 @@ var WindowsMsiTask = &packagingTask{
 				return "", err
 			}
 		case "linux":
-			cmdWixl := exec.Command("wixl", "-v", fmt.Sprintf("%s.wxs", packageName), "-o", outputFileName)
+			cmdWixl := exec.CommandContext(ctx, "wixl", append(append([]string{"-v"}, wixlArgs...), fmt.Sprintf("%s.wxs", packageName), "-o", outputFileName)...)
 			cmdWixl.Dir = tmpPath
 			cmdWixl.Stdout = os.Stdout
 			cmdWixl.Stderr = os.Stderr
@@ -101,114 +111,63 @@ var WindowsMsiTask = &packagingTask{
 			"wixl": "Install msitools from your package manager or from https://wiki.gnome.org/msitools/",
 		},
 	},
-	generateInitFiles: func(packageName, path string) {
+	generateInitFiles: func(packageName, path string) error {
 		err := ioutil.WriteFile(
 			filepath.Join(path, "upgrade-code.txt"),
 			[]byte(fmt.Sprintf("%s\n# This GUID is your upgrade code and ensures that you can properly update your app.\n# Don't change it.", uuid.New())),
 			0755,
 		)
 		if err != nil {
-			log.Errorf("Failed to create `upgrade-code.txt` file: %v", err)
-			os.Exit(1)
+			return errors.Wrap(err, "failed to create `upgrade-code.txt` file")
 		}
+		return nil
 	},
-	extraTemplateData: func(packageName, path string) map[string]string {
+	extraTemplateData: func(packageName, path string) (map[string]string, error) {
 		data, err := ioutil.ReadFile(filepath.Join(path, "upgrade-code.txt"))
 		if err != nil {
-			log.Errorf("Failed to read `go/packaging/windows-msi/upgrade-code.txt`: %v", err)
 			if os.IsNotExist(err) {
 				log.Errorf("Please re-init windows-msi to generate the `go/packaging/windows-msi/upgrade-code.txt`")
 				log.Errorf("or put a GUID from https://www.guidgen.com/ into a new `go/packaging/windows-msi/upgrade-code.txt` file.")
 			}
-			os.Exit(1)
+			return nil, errors.Wrap(err, "failed to read `go/packaging/windows-msi/upgrade-code.txt`")
 		}
 		guid := strings.Split(string(data), "\n")[0]
 		return map[string]string{
 			"upgradeCode":   guid,
 			"pathSeparator": string(os.PathSeparator),
-		}
+		}, nil
 	},
-	generateBuildFiles: func(packageName, tmpPath string) {
-		directoriesFilePath, err := filepath.Abs(filepath.Join(tmpPath, "directories.wxi"))
-		if err != nil {
-			log.Errorf("Failed to resolve absolute path for directories.wxi file %s: %v", packageName, err)
-			os.Exit(1)
-		}
-		directoriesFile, err := os.Create(directoriesFilePath)
+	generateBuildFiles: func(packageName, tmpPath string) error {
+		directoriesFileContent = []string{"<Include>"}
+		directoryRefsFileContent = []string{"<Include>"}
+		componentRefsFileContent = []string{"<Include>"}
+		err := windowsMsiProcessFiles(filepath.Join(tmpPath, "build"))
 		if err != nil {
-			log.Errorf("Failed to create directories.wxi file %s: %v", packageName, err)
-			os.Exit(1)
+			return err
 		}
-		directoryRefsFilePath, err := filepath.Abs(filepath.Join(tmpPath, "directory_refs.wxi"))
-		if err != nil {
-			log.Errorf("Failed to resolve absolute path for directory_refs.wxi file %s: %v", packageName, err)
-			os.Exit(1)
-		}
-		directoryRefsFile, err := os.Create(directoryRefsFilePath)
-		if err != nil {
-			log.Errorf("Failed to create directory_refs.wxi file %s: %v", packageName, err)
-			os.Exit(1)
-		}
-		componentRefsFilePath, err := filepath.Abs(filepath.Join(tmpPath, "component_refs.wxi"))
-		if err != nil {
-			log.Errorf("Failed to resolve absolute path for component_refs.wxi file %s: %v", packageName, err)
-			os.Exit(1)
-		}
-		componentRefsFile, err := os.Create(componentRefsFilePath)
-		if err != nil {
-			log.Errorf("Failed to create component_refs.wxi file %s: %v", packageName, err)
-			os.Exit(1)
-		}
-		directoriesFileContent = append(directoriesFileContent, "<Include>")
-		directoryRefsFileContent = append(directoryRefsFileContent, "<Include>")
-		componentRefsFileContent = append(componentRefsFileContent, "<Include>")
-		windowsMsiProcessFiles(filepath.Join(tmpPath, "build"))
 		directoriesFileContent = append(directoriesFileContent, "</Include>")
 		directoryRefsFileContent = append(directoryRefsFileContent, "</Include>")
 		componentRefsFileContent = append(componentRefsFileContent, "</Include>")
 
-		for _, line := range directoriesFileContent {
-			if _, err := directoriesFile.WriteString(line + "\n"); err != nil {
-				log.Errorf("Could not write directories.wxi: %v", packageName, err)
-				os.Exit(1)
-			}
-		}
-		err = directoriesFile.Close()
-		if err != nil {
-			log.Errorf("Could not close directories.wxi: %v", packageName, err)
-			os.Exit(1)
-		}
-		for _, line := range directoryRefsFileContent {
-			if _, err := directoryRefsFile.WriteString(line + "\n"); err != nil {
-				log.Errorf("Could not write directory_refs.wxi: %v", packageName, err)
-				os.Exit(1)
-			}
-		}
-		err = directoryRefsFile.Close()
-		if err != nil {
-			log.Errorf("Could not close directory_refs.wxi: %v", packageName, err)
-			os.Exit(1)
-		}
-		for _, line := range componentRefsFileContent {
-			if _, err := componentRefsFile.WriteString(line + "\n"); err != nil {
-				log.Errorf("Could not write component_refs.wxi: %v", packageName, err)
-				os.Exit(1)
+		for name, content := range map[string][]string{
+			"directories.wxi":    directoriesFileContent,
+			"directory_refs.wxi": directoryRefsFileContent,
+			"component_refs.wxi": componentRefsFileContent,
+		} {
+			err = ioutil.WriteFile(filepath.Join(tmpPath, name), []byte(strings.Join(content, "\n")+"\n"), 0644)
+			if err != nil {
+				return errors.Wrapf(err, "could not write %s", name)
 			}
 		}
-		err = componentRefsFile.Close()
-		if err != nil {
-			log.Errorf("Could not close component_refs.wxi: %v", packageName, err)
-			os.Exit(1)
-		}
+		return nil
 	},
 }
 
-func windowsMsiProcessFiles(path string) {
+func windowsMsiProcessFiles(path string) error {
 	pathSeparator := string(os.PathSeparator)
 	files, err := ioutil.ReadDir(path)
 	if err != nil {
-		log.Errorf("Failed to read directory %s: %v", path, err)
-		os.Exit(1)
+		return errors.Wrapf(err, "failed to read directory %s", path)
 	}
 
 	for _, f := range files {
@@ -219,7 +178,10 @@ func windowsMsiProcessFiles(path string) {
 			directoriesFileContent = append(directoriesFileContent,
 				fmt.Sprintf(`<Directory Id="APPLICATIONROOTDIRECTORY_%s" Name="%s">`, id, f.Name()),
 			)
-			windowsMsiProcessFiles(p)
+			err = windowsMsiProcessFiles(p)
+			if err != nil {
+				return err
+			}
 			directoriesFileContent = append(directoriesFileContent,
 				"</Directory>",
 			)
@@ -245,6 +207,7 @@ func windowsMsiProcessFiles(path string) {
 			)
 		}
 	}
+	return nil
 }
 
 func hashSha1(content string) string {
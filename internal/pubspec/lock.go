@@ -0,0 +1,55 @@
+package pubspec
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// lockPackage is one entry of the packages of pubspec.lock.
+type lockPackage struct {
+	Source      string
+	Description interface{}
+}
+
+// PathDependencies returns the directories of the packages of the working
+// directory pubspec.lock which are `path` dependencies, sorted. A missing
+// pubspec.lock has no dependencies.
+func PathDependencies() ([]string, error) {
+	file, err := os.Open("pubspec.lock")
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open pubspec.lock")
+	}
+	defer file.Close()
+
+	var lock struct {
+		Packages map[string]lockPackage
+	}
+	err = yaml.NewDecoder(file).Decode(&lock)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode pubspec.lock")
+	}
+	var paths []string
+	for _, p := range lock.Packages {
+		if p.Source != "path" {
+			continue
+		}
+		description, ok := p.Description.(map[interface{}]interface{})
+		if !ok {
+			continue
+		}
+		path, ok := description["path"].(string)
+		if !ok {
+			continue
+		}
+		paths = append(paths, filepath.FromSlash(path))
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
@@ -0,0 +1,49 @@
+package pubspec
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathDependencies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hover-pubspec")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	paths, err := PathDependencies()
+	require.NoError(t, err)
+	require.Empty(t, paths)
+
+	require.NoError(t, ioutil.WriteFile("pubspec.lock", []byte(`packages:
+  collection:
+    dependency: transitive
+    description:
+      name: collection
+      url: "https://pub.dartlang.org"
+    source: hosted
+    version: "1.15.0"
+  widgets:
+    dependency: "direct main"
+    description:
+      path: "../widgets"
+      relative: true
+    source: path
+    version: "0.0.1"
+  flutter:
+    dependency: "direct main"
+    description: flutter
+    source: sdk
+    version: "0.0.0"
+`), 0644))
+	paths, err = PathDependencies()
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join("..", "widgets")}, paths)
+}
@@ -40,12 +40,10 @@ func (p PubSpec) GetVersion() string {
 
 func (p PubSpec) GetAuthor() string {
 	if len(p.Author) == 0 {
-		u, err := user.Current()
-		if err != nil {
-			log.Errorf("Couldn't get current user: %v", err)
-			os.Exit(1)
+		p.Author = "unknown"
+		if u, err := user.Current(); err == nil {
+			p.Author = u.Username
 		}
-		p.Author = u.Username
 		config.PrintMissingField("author", "pubspec.yaml", p.Author)
 	}
 	return p.Author
@@ -53,18 +51,30 @@ func (p PubSpec) GetAuthor() string {
 
 var pubspec = PubSpec{}
 
-// GetPubSpec returns the working directory pubspec.yaml as a PubSpec
+// GetPubSpec returns the working directory pubspec.yaml as a PubSpec. It
+// exits when pubspec.yaml cannot be read, use LoadPubSpec outside of the
+// command layer.
 func GetPubSpec() PubSpec {
+	pub, err := LoadPubSpec()
+	if err != nil {
+		log.Errorf("%v", err)
+		log.Errorf("This command should be run from the root of your Flutter project.")
+		os.Exit(1)
+	}
+	return pub
+}
+
+// LoadPubSpec returns the working directory pubspec.yaml as a PubSpec. The
+// result is cached.
+func LoadPubSpec() (PubSpec, error) {
 	if pubspec.Name == "" {
 		pub, err := ReadPubSpecFile("pubspec.yaml")
 		if err != nil {
-			log.Errorf("%v", err)
-			log.Errorf("This command should be run from the root of your Flutter project.")
-			os.Exit(1)
+			return PubSpec{}, err
 		}
 		pubspec = *pub
 	}
-	return pubspec
+	return pubspec, nil
 }
 
 // ReadPubSpecFile reads a .yaml file at a path and return a correspond
@@ -2,31 +2,59 @@ package version
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"os"
 	"os/exec"
 	"runtime/debug"
 	"sync"
 
+	"github.com/pkg/errors"
+
 	"github.com/go-flutter-desktop/hover/internal/build"
 	"github.com/go-flutter-desktop/hover/internal/log"
 )
 
 // FlutterRequiredEngineVersion returns the commit hash of the engine in use
-func FlutterRequiredEngineVersion() string {
-	return readFlutterVersion().EngineRevision
+func FlutterRequiredEngineVersion(ctx context.Context) (string, error) {
+	flutterVersion, err := readFlutterVersion(ctx)
+	return flutterVersion.EngineRevision, err
 }
 
 // FlutterChannel returns the channel of the flutter installation
-func FlutterChannel() string {
-	return readFlutterVersion().Channel
+func FlutterChannel(ctx context.Context) (string, error) {
+	flutterVersion, err := readFlutterVersion(ctx)
+	return flutterVersion.Channel, err
 }
 
-func readFlutterVersion() flutterVersionResponse {
-	out, err := exec.Command(build.FlutterBin(), "--version", "--machine").Output()
+var (
+	flutterVersionValue flutterVersionResponse
+	flutterVersionMutex sync.Mutex
+)
+
+// readFlutterVersion runs `flutter --version` once, later calls return the
+// same result.
+func readFlutterVersion(ctx context.Context) (flutterVersionResponse, error) {
+	flutterVersionMutex.Lock()
+	defer flutterVersionMutex.Unlock()
+	if flutterVersionValue.EngineRevision != "" {
+		return flutterVersionValue, nil
+	}
+	response, err := runFlutterVersion(ctx)
+	if err != nil {
+		return flutterVersionResponse{}, err
+	}
+	flutterVersionValue = response
+	return response, nil
+}
+
+func runFlutterVersion(ctx context.Context) (flutterVersionResponse, error) {
+	flutterBin, err := build.FlutterBin()
+	if err != nil {
+		return flutterVersionResponse{}, err
+	}
+	out, err := exec.CommandContext(ctx, flutterBin, "--version", "--machine").Output()
 	if err != nil {
-		log.Errorf("Failed to run %s: %v", log.Au().Magenta("flutter --version --machine"), err)
-		os.Exit(1)
+		return flutterVersionResponse{}, errors.Wrap(err, "failed to run flutter --version --machine")
 	}
 
 	// Read bytes from the stdout until we receive what looks like the start of
@@ -36,8 +64,7 @@ func readFlutterVersion() flutterVersionResponse {
 	for {
 		b, err := outputBuffer.ReadByte()
 		if err != nil {
-			log.Errorf("Failed to run %s: did not return information in json", log.Au().Magenta("flutter --version --machine"))
-			os.Exit(1)
+			return flutterVersionResponse{}, errors.New("failed to run flutter --version --machine: did not return information in json")
 		}
 		if b == '{' {
 			outputBuffer.UnreadByte()
@@ -48,10 +75,9 @@ func readFlutterVersion() flutterVersionResponse {
 	var response flutterVersionResponse
 	err = json.NewDecoder(outputBuffer).Decode(&response)
 	if err != nil {
-		log.Errorf("Failed parsing json: %v", err)
-		os.Exit(1)
+		return flutterVersionResponse{}, errors.Wrap(err, "failed parsing json")
 	}
-	return response
+	return response, nil
 }
 
 type flutterVersionResponse struct {
@@ -64,12 +90,15 @@ var (
 	hoverVersionOnce  sync.Once
 )
 
+// HoverVersion returns the version of the hover module, or "unknown" when
+// the binary has no build information.
 func HoverVersion() string {
 	hoverVersionOnce.Do(func() {
 		buildInfo, ok := debug.ReadBuildInfo()
 		if !ok {
-			log.Errorf("Cannot obtain version information from hover build. To resolve this, please go-get hover using Go 1.13 or newer.")
-			os.Exit(1)
+			log.Warnf("Cannot obtain version information from hover build. To resolve this, please go-get hover using Go 1.13 or newer.")
+			hoverVersionValue = "unknown"
+			return
 		}
 		hoverVersionValue = buildInfo.Main.Version
 	})
@@ -104,8 +104,8 @@ func CheckForHoverUpdate(currentVersion string) {
 	if currentVersion != "(devel)" {
 		cacheDir, err := os.UserCacheDir()
 		if err != nil {
-			log.Errorf("Failed to get cache directory: %v", err)
-			os.Exit(1)
+			log.Warnf("Failed to get cache directory: %v", err)
+			return
 		}
 		update, newVersion := hasUpdate(filepath.Join(cacheDir, "hover"), currentVersion, "hover")
 		if update {
@@ -121,7 +121,10 @@ func CheckForHoverUpdate(currentVersion string) {
 // than the current one, display the update notice.
 func CheckForGoFlutterUpdate(goDirectoryPath string, currentTag string) {
 	hoverGitignore := filepath.Join(goDirectoryPath, ".gitignore")
-	fileutils.AddLineToFile(hoverGitignore, ".last_go-flutter_check")
+	err := fileutils.AddLineToFile(hoverGitignore, ".last_go-flutter_check")
+	if err != nil {
+		log.Warnf("%v", err)
+	}
 	update, newVersion := hasUpdate(goDirectoryPath, currentTag, "go-flutter")
 	if update {
 		log.Infof("The core library 'go-flutter' has an update available. (%s -> %s)", currentTag, newVersion)
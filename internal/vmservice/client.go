@@ -0,0 +1,205 @@
+// Package vmservice is a client of the Dart VM service protocol, the
+// JSON-RPC API served over WebSocket by the observatory of debug and profile
+// apps. See https://github.com/dart-lang/sdk/blob/main/runtime/vm/service/service.md
+package vmservice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/websocket"
+)
+
+// Error is an error returned by the VM service.
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if len(e.Data) > 0 {
+		return fmt.Sprintf("vm service error %d: %s: %s", e.Code, e.Message, e.Data)
+	}
+	return fmt.Sprintf("vm service error %d: %s", e.Code, e.Message)
+}
+
+type request struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      string      `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// message is a response to a request, or an event when Method is set.
+type message struct {
+	ID     string          `json:"id"`
+	Method string          `json:"method"`
+	Result json.RawMessage `json:"result"`
+	Error  *Error          `json:"error"`
+	Params json.RawMessage `json:"params"`
+}
+
+// Client is a connection to the VM service of a running app. Its methods
+// can be called concurrently.
+type Client struct {
+	conn *websocket.Conn
+	uri  string
+
+	mu      sync.Mutex
+	nextID  int
+	pending map[string]chan message
+	err     error
+	done    chan struct{}
+}
+
+// WebSocketURI returns the WebSocket URI of the VM service of an app from
+// the http URI of its observatory, as printed by the app, e.g.
+// http://127.0.0.1:50300/ or http://127.0.0.1:50300/8sx2lz5kC0s=/.
+func WebSocketURI(observatoryURI string) (string, error) {
+	u, err := url.Parse(observatoryURI)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid observatory URI %q", observatoryURI)
+	}
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	case "ws", "wss":
+		return u.String(), nil
+	default:
+		return "", errors.Errorf("invalid observatory URI %q", observatoryURI)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/ws"
+	return u.String(), nil
+}
+
+// ObservatoryURI returns the observatory URI of an app started with
+// --observatory-port=port and --disable-service-auth-codes.
+func ObservatoryURI(port string) string {
+	return "http://127.0.0.1:" + port + "/"
+}
+
+// Dial connects to the VM service of the app at the observatory URI.
+func Dial(ctx context.Context, observatoryURI string) (*Client, error) {
+	wsURI, err := WebSocketURI(observatoryURI)
+	if err != nil {
+		return nil, err
+	}
+	config, err := websocket.NewConfig(wsURI, "http://localhost/")
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid VM service URI %s", wsURI)
+	}
+	var dialer net.Dialer
+	netConn, err := dialer.DialContext(ctx, "tcp", config.Location.Host)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to the VM service at %s", observatoryURI)
+	}
+	conn, err := websocket.NewClient(config, netConn)
+	if err != nil {
+		netConn.Close()
+		return nil, errors.Wrapf(err, "failed to connect to the VM service at %s", observatoryURI)
+	}
+	c := &Client{
+		conn:    conn,
+		uri:     observatoryURI,
+		pending: make(map[string]chan message),
+		done:    make(chan struct{}),
+	}
+	go c.read()
+	return c, nil
+}
+
+// read dispatches the responses to the pending calls until the connection
+// is closed.
+func (c *Client) read() {
+	var err error
+	for {
+		var m message
+		err = websocket.JSON.Receive(c.conn, &m)
+		if err != nil {
+			break
+		}
+		if m.ID == "" {
+			// events of the streams aren't used
+			continue
+		}
+		c.mu.Lock()
+		response, ok := c.pending[m.ID]
+		delete(c.pending, m.ID)
+		c.mu.Unlock()
+		if ok {
+			response <- m
+		}
+	}
+	c.mu.Lock()
+	c.err = errors.Wrap(err, "the connection to the VM service was closed")
+	c.mu.Unlock()
+	close(c.done)
+}
+
+// Call calls a method of the VM service and decodes its result into
+// result, unless result is nil.
+func (c *Client) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	c.nextID++
+	id := strconv.Itoa(c.nextID)
+	response := make(chan message, 1)
+	c.pending[id] = response
+	c.mu.Unlock()
+
+	err := websocket.JSON.Send(c.conn, request{JSONRPC: "2.0", ID: id, Method: method, Params: params})
+	if err != nil {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return errors.Wrapf(err, "failed to call %s", method)
+	}
+	select {
+	case m := <-response:
+		if m.Error != nil {
+			return errors.Wrapf(m.Error, "%s failed", method)
+		}
+		if result == nil {
+			return nil
+		}
+		return errors.Wrapf(json.Unmarshal(m.Result, result), "invalid result of %s", method)
+	case <-c.done:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.err
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// URI returns the observatory URI the client is connected to.
+func (c *Client) URI() string {
+	return c.uri
+}
+
+// Done is closed when the connection to the VM service is closed, e.g.
+// because the app exited.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Close closes the connection to the VM service.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,134 @@
+package vmservice
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// newTestServer serves a VM service answering with handler, which returns
+// the result or the error of a call.
+func newTestServer(t *testing.T, handler func(method string, params map[string]interface{}) (interface{}, *Error)) string {
+	server := httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		for {
+			var call struct {
+				ID     string                 `json:"id"`
+				Method string                 `json:"method"`
+				Params map[string]interface{} `json:"params"`
+			}
+			if err := websocket.JSON.Receive(conn, &call); err != nil {
+				return
+			}
+			// an event of a stream, which the client ignores
+			websocket.JSON.Send(conn, map[string]interface{}{"jsonrpc": "2.0", "method": "streamNotify", "params": map[string]string{"streamId": "Isolate"}})
+			result, rpcErr := handler(call.Method, call.Params)
+			response := map[string]interface{}{"jsonrpc": "2.0", "id": call.ID}
+			if rpcErr != nil {
+				response["error"] = rpcErr
+			} else {
+				response["result"] = result
+			}
+			websocket.JSON.Send(conn, response)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server.URL + "/"
+}
+
+func TestWebSocketURI(t *testing.T) {
+	uri, err := WebSocketURI("http://127.0.0.1:50300/")
+	require.NoError(t, err)
+	require.Equal(t, "ws://127.0.0.1:50300/ws", uri)
+
+	uri, err = WebSocketURI("http://127.0.0.1:41234/8sx2lz5kC0s=/")
+	require.NoError(t, err)
+	require.Equal(t, "ws://127.0.0.1:41234/8sx2lz5kC0s=/ws", uri)
+
+	_, err = WebSocketURI("127.0.0.1:50300")
+	require.Error(t, err)
+
+	devtools, err := DevToolsURL("http://127.0.0.1:9100/", "http://127.0.0.1:50300/")
+	require.NoError(t, err)
+	require.Equal(t, "http://127.0.0.1:9100/?uri=ws%3A%2F%2F127.0.0.1%3A50300%2Fws", devtools)
+}
+
+func TestReload(t *testing.T) {
+	var reloaded, reassembled []string
+	uri := newTestServer(t, func(method string, params map[string]interface{}) (interface{}, *Error) {
+		switch method {
+		case "_flutter.listViews":
+			return json.RawMessage(`{"type": "FlutterViewList", "views": [{"type": "FlutterView", "id": "_flutterView/0x1", "isolate": {"type": "@Isolate", "id": "isolates/42", "name": "main"}}]}`), nil
+		case "reloadSources":
+			reloaded = append(reloaded, params["isolateId"].(string)+" "+params["rootLibUri"].(string))
+			if params["rootLibUri"] == "file:///tmp/broken.dill" {
+				return json.RawMessage(`{"type": "ReloadReport", "success": false, "notices": [{"type": "ReasonForCancelling", "message": "Const class cannot become non-const"}]}`), nil
+			}
+			return json.RawMessage(`{"type": "ReloadReport", "success": true}`), nil
+		case "ext.flutter.reassemble":
+			reassembled = append(reassembled, params["isolateId"].(string))
+			return json.RawMessage(`{"type": "Success"}`), nil
+		default:
+			return nil, &Error{Code: -32601, Message: "Method not found"}
+		}
+	})
+
+	ctx := context.Background()
+	client, err := Dial(ctx, uri)
+	require.NoError(t, err)
+	defer client.Close()
+
+	views, err := client.ListViews(ctx)
+	require.NoError(t, err)
+	require.Len(t, views, 1)
+	require.Equal(t, "isolates/42", views[0].Isolate.ID)
+
+	report, err := client.ReloadSources(ctx, "isolates/42", "file:///tmp/app.dill.incremental.dill")
+	require.NoError(t, err)
+	require.True(t, report.Success)
+	err = client.CallServiceExtension(ctx, "isolates/42", "ext.flutter.reassemble", nil, nil)
+	require.NoError(t, err)
+
+	report, err = client.ReloadSources(ctx, "isolates/42", "file:///tmp/broken.dill")
+	require.NoError(t, err)
+	require.False(t, report.Success)
+	require.Equal(t, "Const class cannot become non-const", report.Reasons())
+
+	require.Equal(t, []string{"isolates/42 file:///tmp/app.dill.incremental.dill", "isolates/42 file:///tmp/broken.dill"}, reloaded)
+	require.Equal(t, []string{"isolates/42"}, reassembled)
+
+	_, err = client.GetVM(ctx)
+	require.Error(t, err)
+	rpcErr, ok := errors.Cause(err).(*Error)
+	require.True(t, ok)
+	require.Equal(t, -32601, rpcErr.Code)
+}
+
+func TestConnectionClosed(t *testing.T) {
+	uri := newTestServer(t, func(method string, params map[string]interface{}) (interface{}, *Error) {
+		return nil, nil
+	})
+	client, err := Dial(context.Background(), uri)
+	require.NoError(t, err)
+	client.Close()
+	<-client.Done()
+	_, err = client.GetVM(context.Background())
+	require.Error(t, err)
+}
+
+func TestGetVM(t *testing.T) {
+	uri := newTestServer(t, func(method string, params map[string]interface{}) (interface{}, *Error) {
+		return json.RawMessage(`{"type": "VM", "version": "2.19.0", "pid": 4242, "isolates": [{"type": "@Isolate", "id": "isolates/42", "name": "main"}]}`), nil
+	})
+	client, err := Dial(context.Background(), uri)
+	require.NoError(t, err)
+	defer client.Close()
+	vm, err := client.GetVM(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 4242, vm.Pid)
+	require.Len(t, vm.Isolates, 1)
+}
@@ -0,0 +1,119 @@
+package vmservice
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// IsolateRef is a reference to an isolate of the VM.
+type IsolateRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// VM describes the VM of the app.
+type VM struct {
+	Version  string       `json:"version"`
+	Isolates []IsolateRef `json:"isolates"`
+	// Pid is the process id of the app.
+	Pid int `json:"pid"`
+}
+
+// FlutterView is a flutter view of the app and the isolate running it.
+type FlutterView struct {
+	ID      string      `json:"id"`
+	Isolate *IsolateRef `json:"isolate"`
+}
+
+// ReloadReport is the result of reloadSources.
+type ReloadReport struct {
+	Success bool `json:"success"`
+	Notices []struct {
+		Message string `json:"message"`
+	} `json:"notices"`
+}
+
+// Reasons returns the reasons of a failed reload.
+func (r ReloadReport) Reasons() string {
+	var reasons []string
+	for _, notice := range r.Notices {
+		reasons = append(reasons, notice.Message)
+	}
+	return strings.Join(reasons, "\n")
+}
+
+// GetVM returns the description of the VM.
+func (c *Client) GetVM(ctx context.Context) (VM, error) {
+	var vm VM
+	err := c.Call(ctx, "getVM", nil, &vm)
+	return vm, err
+}
+
+// ListViews returns the flutter views of the app.
+func (c *Client) ListViews(ctx context.Context) ([]FlutterView, error) {
+	var list struct {
+		Views []FlutterView `json:"views"`
+	}
+	err := c.Call(ctx, "_flutter.listViews", nil, &list)
+	return list.Views, err
+}
+
+// ReloadSources loads the kernel at rootLibURI, a .dill file, in the
+// isolate. The classes and functions are replaced, the state is kept.
+func (c *Client) ReloadSources(ctx context.Context, isolateID, rootLibURI string) (ReloadReport, error) {
+	var report ReloadReport
+	err := c.Call(ctx, "reloadSources", map[string]interface{}{
+		"isolateId":  isolateID,
+		"rootLibUri": rootLibURI,
+		"pause":      false,
+	}, &report)
+	return report, err
+}
+
+// CallServiceExtension calls an extension registered by the app in the
+// isolate, such as ext.flutter.reassemble.
+func (c *Client) CallServiceExtension(ctx context.Context, isolateID, method string, params map[string]interface{}, result interface{}) error {
+	args := map[string]interface{}{"isolateId": isolateID}
+	for key, value := range params {
+		args[key] = value
+	}
+	return c.Call(ctx, method, args, result)
+}
+
+// RunInView restarts the view with the kernel at mainScript, a .dill file,
+// and the flutter assets of assetDirectory. The state of the app is lost.
+func (c *Client) RunInView(ctx context.Context, viewID, mainScript, assetDirectory string) error {
+	return c.Call(ctx, "_flutter.runInView", map[string]interface{}{
+		"viewId":         viewID,
+		"mainScript":     mainScript,
+		"assetDirectory": assetDirectory,
+	}, nil)
+}
+
+// SetVMTimelineFlags selects the streams of events recorded in the timeline,
+// e.g. Dart, Embedder and GC. The timeline isn't recorded without streams.
+func (c *Client) SetVMTimelineFlags(ctx context.Context, recordedStreams []string) error {
+	return c.Call(ctx, "setVMTimelineFlags", map[string]interface{}{
+		"recordedStreams": recordedStreams,
+	}, nil)
+}
+
+// GetVMTimeline returns the recorded timeline, in the trace event format
+// read by DevTools, chrome://tracing and Perfetto.
+func (c *Client) GetVMTimeline(ctx context.Context) (json.RawMessage, error) {
+	var timeline json.RawMessage
+	err := c.Call(ctx, "getVMTimeline", nil, &timeline)
+	return timeline, err
+}
+
+// DevToolsURL returns the URL of DevTools served at server, inspecting the
+// app of the observatory URI.
+func DevToolsURL(server, observatoryURI string) (string, error) {
+	wsURI, err := WebSocketURI(observatoryURI)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(server, "/") + "/?uri=" + url.QueryEscape(wsURI), nil
+}
@@ -0,0 +1,109 @@
+// Package watch detects the changes of the files in directory trees, by
+// polling their modification times.
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Filter selects the files and directories to watch. Returning false for a
+// directory skips the files it contains.
+type Filter func(path string, info os.FileInfo) bool
+
+// fileState is what is compared to detect the change of a file.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Poll watches the files selected by filter in the paths, every interval,
+// until ctx is done. The paths of the changed, created and removed files are
+// sent on the returned channel once a poll finds no further changes, so that
+// the files saved together are reported together.
+func Poll(ctx context.Context, interval time.Duration, paths []string, filter Filter) <-chan []string {
+	changes := make(chan []string)
+	go func() {
+		defer close(changes)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		previous := TakeSnapshot(paths, filter)
+		pending := make(map[string]bool)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			current := TakeSnapshot(paths, filter)
+			changed := previous.Changed(current)
+			previous = current
+			for _, path := range changed {
+				pending[path] = true
+			}
+			if len(changed) > 0 || len(pending) == 0 {
+				continue
+			}
+			var paths []string
+			for path := range pending {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			pending = make(map[string]bool)
+			select {
+			case changes <- paths:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return changes
+}
+
+// Snapshot is the state of the watched files at a point in time.
+type Snapshot map[string]fileState
+
+// TakeSnapshot returns the state of the files selected by filter in the
+// paths. The files that can't be read are left out, they are reported as
+// removed.
+func TakeSnapshot(paths []string, filter Filter) Snapshot {
+	states := make(Snapshot)
+	for _, root := range paths {
+		filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if !filter(path, info) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.IsDir() {
+				states[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+			}
+			return nil
+		})
+	}
+	return states
+}
+
+// Changed returns the paths of the files that differ between the snapshots
+// previous and current.
+func (previous Snapshot) Changed(current Snapshot) []string {
+	var changed []string
+	for path, state := range current {
+		if previousState, ok := previous[path]; !ok || previousState != state {
+			changed = append(changed, path)
+		}
+	}
+	for path := range previous {
+		if _, ok := current[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,43 @@
+package watch
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoll(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "build"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte("package main"), 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	filter := func(path string, info os.FileInfo) bool {
+		if info.IsDir() {
+			return info.Name() != "build"
+		}
+		return strings.HasSuffix(path, ".go")
+	}
+	changes := Poll(ctx, 10*time.Millisecond, []string{dir}, filter)
+
+	// wait for the first snapshot before changing the files
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "build", "ignored.go"), []byte("package build"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "plugin.go"), []byte("package main"), 0644))
+	require.NoError(t, os.Remove(filepath.Join(dir, "main.go")))
+
+	select {
+	case paths := <-changes:
+		require.Equal(t, []string{filepath.Join(dir, "main.go"), filepath.Join(dir, "plugin.go")}, paths)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the changes weren't reported")
+	}
+}
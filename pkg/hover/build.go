@@ -0,0 +1,551 @@
+package hover
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/otiai10/copy"
+	"github.com/pkg/errors"
+
+	"github.com/go-flutter-desktop/hover/internal/aot"
+	"github.com/go-flutter-desktop/hover/internal/build"
+	"github.com/go-flutter-desktop/hover/internal/config"
+	"github.com/go-flutter-desktop/hover/internal/darwinhacks"
+	"github.com/go-flutter-desktop/hover/internal/enginecache"
+	"github.com/go-flutter-desktop/hover/internal/fileutils"
+	"github.com/go-flutter-desktop/hover/internal/log"
+	"github.com/go-flutter-desktop/hover/internal/pubspec"
+	"github.com/go-flutter-desktop/hover/internal/versioncheck"
+)
+
+var dotSlash = string([]byte{'.', filepath.Separator})
+
+// crossCompilerBinNames contains the C compilers used when cross-compiling
+// from linux, indexed by target OS and then by architecture.
+var crossCompilerBinNames = map[string]map[string]string{
+	"windows": {
+		"amd64": "x86_64-w64-mingw32-gcc",
+		"arm64": "aarch64-w64-mingw32-gcc",
+	},
+	"darwin": {
+		"amd64": "o32-clang",
+		"arm64": "oa64-clang",
+	},
+	"linux": {
+		"amd64": "x86_64-linux-gnu-gcc",
+		"arm64": "aarch64-linux-gnu-gcc",
+	},
+}
+
+// builder builds the app for a single target OS. engineDirectoryPath is the
+// directory of the cached engine, or of the local engine build.
+type builder struct {
+	opts                       BuildOptions
+	engineDirectoryPath        string
+	outputDirectoryPath        string
+	intermediatesDirectoryPath string
+	outputBinaryPath           string
+}
+
+func newBuilder(ctx context.Context, opts BuildOptions) (*builder, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	err = opts.validate()
+	if err != nil {
+		return nil, err
+	}
+	b := &builder{opts: opts}
+	if opts.LocalEngine != "" {
+		b.engineDirectoryPath = opts.localEnginePath()
+	} else {
+		b.opts.EngineVersion, err = enginecache.ResolveEngineVersion(ctx, opts.EngineVersion)
+		if err != nil {
+			return nil, err
+		}
+		b.engineDirectoryPath = enginecache.EngineCachePath(opts.TargetOS, opts.Arch, opts.CachePath, b.opts.EngineVersion, opts.Mode)
+	}
+	b.outputDirectoryPath, err = build.OutputDirectoryPath(opts.TargetOS, opts.Arch, opts.Mode)
+	if err != nil {
+		return nil, err
+	}
+	b.intermediatesDirectoryPath, err = build.IntermediatesDirectoryPath(opts.TargetOS, opts.Arch, opts.Mode)
+	if err != nil {
+		return nil, err
+	}
+	b.outputBinaryPath, err = outputBinaryPath(opts.TargetOS, opts.Arch, opts.Mode)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Build builds the flutter bundle and the go binary of the app for
+// opts.TargetOS. The engine is downloaded first unless
+// opts.SkipEngineDownload is set. A stage is skipped when its inputs didn't
+// change since the last build and its outputs are still present, unless
+// opts.Force is set.
+func Build(ctx context.Context, opts BuildOptions) error {
+	b, err := newBuilder(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if b.opts.Reproducible {
+		err = setSourceDateEpoch(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	if !b.opts.SkipEngineDownload && b.opts.LocalEngine == "" {
+		err = PrepareEngine(ctx, b.opts)
+		if err != nil {
+			return err
+		}
+	}
+	return b.buildStages(ctx)
+}
+
+func (b *builder) cleanBuildOutputsDir() error {
+	log.Printf("Cleaning the build directory")
+	err := os.RemoveAll(b.outputDirectoryPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to remove output directory %s", b.outputDirectoryPath)
+	}
+	err = os.MkdirAll(b.outputDirectoryPath, 0775)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create output directory %s", b.outputDirectoryPath)
+	}
+	return nil
+}
+
+func (b *builder) buildFlutterBundle(ctx context.Context) error {
+	if !fileExists(b.opts.FlutterTarget) {
+		return errors.Errorf("target file %q not found", b.opts.FlutterTarget)
+	}
+	flutterBin, err := build.FlutterBin()
+	if err != nil {
+		return err
+	}
+
+	flutterBuildArgs := []string{"build", "bundle",
+		"--asset-dir", filepath.Join(b.outputDirectoryPath, "flutter_assets"),
+		"--target", b.opts.FlutterTarget,
+	}
+	if b.opts.Mode == build.DebugMode {
+		flutterBuildArgs = append(flutterBuildArgs, "--track-widget-creation")
+	}
+	for _, define := range b.opts.DartDefines {
+		flutterBuildArgs = append(flutterBuildArgs, "--dart-define="+define)
+	}
+
+	cmdFlutterBuild := exec.CommandContext(ctx, flutterBin, flutterBuildArgs...)
+	cmdFlutterBuild.Stderr = os.Stderr
+	cmdFlutterBuild.Stdout = os.Stdout
+
+	log.Infof("Bundling flutter app")
+	err = cmdFlutterBuild.Run()
+	if err != nil {
+		return errors.Wrap(err, "flutter build failed")
+	}
+	if b.opts.Mode.IsAot {
+		for _, name := range []string{"isolate_snapshot_data", "vm_snapshot_data", "kernel_blob.bin"} {
+			err = os.Remove(filepath.Join(b.outputDirectoryPath, "flutter_assets", name))
+			if err != nil {
+				return errors.Wrapf(err, "failed to remove unused %s", name)
+			}
+		}
+		return b.buildAotSnapshot(ctx)
+	}
+	return nil
+}
+
+// reuseFlutterBundle copies the flutter assets built for another OS and, in
+// AOT mode, compiles the snapshot for the target OS. The flutter assets don't
+// depend on the OS, this avoids running `flutter build bundle` for each OS.
+func (b *builder) reuseFlutterBundle(ctx context.Context) error {
+	bundleOS := b.opts.FlutterBundleOS
+	log.Infof("Reusing flutter bundle of %s", bundleOS)
+	bundleOutputDirectoryPath, err := build.OutputDirectoryPath(bundleOS, b.opts.Arch, b.opts.Mode)
+	if err != nil {
+		return err
+	}
+	err = copy.Copy(
+		filepath.Join(bundleOutputDirectoryPath, "flutter_assets"),
+		filepath.Join(b.outputDirectoryPath, "flutter_assets"),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed to copy the flutter bundle of %s", bundleOS)
+	}
+	if b.opts.Mode.IsAot {
+		return b.buildAotSnapshot(ctx)
+	}
+	return nil
+}
+
+// buildAotSnapshot compiles the dart code into the libapp.so ELF snapshot.
+func (b *builder) buildAotSnapshot(ctx context.Context) error {
+	runner, err := aot.NewRunner(b.opts.TargetOS)
+	if err != nil {
+		return err
+	}
+	return aot.Build(ctx, runner, b.aotSnapshot())
+}
+
+// aotSnapshot returns the inputs of the AOT snapshot of the app.
+func (b *builder) aotSnapshot() aot.Snapshot {
+	return aot.Snapshot{
+		Dart:               b.engineFile("dart" + build.ExecutableExtension(b.opts.TargetOS)),
+		FrontendServer:     b.engineFile(filepath.Join("gen", "frontend_server.dart.snapshot")),
+		FlutterPatchedSdk:  b.engineFile("flutter_patched_sdk"),
+		GenSnapshot:        b.engineFile("gen_snapshot" + build.ExecutableExtension(b.opts.TargetOS)),
+		Packages:           ".packages",
+		Target:             b.opts.FlutterTarget,
+		DartDefines:        b.opts.DartDefines,
+		OutputDirectory:    b.outputDirectoryPath,
+		Strip:              b.opts.Mode == build.ReleaseMode,
+		Obfuscate:          b.opts.Obfuscate,
+		DebugInfoPath:      b.debugInfoPath(),
+		ObfuscationMapPath: b.obfuscationMapPath(),
+	}
+}
+
+// debugInfoPath returns the file the debugging information of the AOT
+// snapshot is saved to, or an empty string when it stays in the snapshot.
+func (b *builder) debugInfoPath() string {
+	if b.opts.SplitDebugInfo == "" {
+		return ""
+	}
+	return filepath.Join(b.opts.SplitDebugInfo, b.opts.VersionNumber, fmt.Sprintf("app.%s-%s.symbols", b.opts.TargetOS, b.opts.Arch))
+}
+
+// obfuscationMapPath returns the file the obfuscation map of the AOT snapshot
+// is saved to, next to its debugging information, or an empty string when
+// the snapshot isn't obfuscated.
+func (b *builder) obfuscationMapPath() string {
+	if !b.opts.Obfuscate || b.opts.SplitDebugInfo == "" {
+		return ""
+	}
+	return filepath.Join(b.opts.SplitDebugInfo, b.opts.VersionNumber, fmt.Sprintf("app.%s-%s.obfuscation.json", b.opts.TargetOS, b.opts.Arch))
+}
+
+func (b *builder) buildGoBinary(ctx context.Context) error {
+	err := fileutils.CopyDir(b.intermediatesDirectoryPath, b.outputDirectoryPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to copy the intermediates")
+	}
+
+	err = b.copyEngineFiles(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = fileutils.CopyDir(
+		filepath.Join(build.BuildPath, "assets"),
+		filepath.Join(b.outputDirectoryPath, "assets"),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to copy the assets")
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return errors.Wrap(err, "failed to get working dir")
+	}
+
+	if b.opts.OpenGlVersion == "none" {
+		log.Warnf("The '--opengl=none' flag makes go-flutter incompatible with texture plugins!")
+	}
+
+	buildCommandString, err := b.buildCommand()
+	if err != nil {
+		return err
+	}
+	buildEnv, err := b.buildEnv()
+	if err != nil {
+		return err
+	}
+	cmdGoBuild := exec.CommandContext(ctx, buildCommandString[0], buildCommandString[1:]...)
+	cmdGoBuild.Dir = filepath.Join(wd, build.BuildPath)
+	cmdGoBuild.Env = append(os.Environ(), buildEnv...)
+
+	cmdGoBuild.Stderr = os.Stderr
+	cmdGoBuild.Stdout = os.Stdout
+
+	log.Infof("Compiling 'go-flutter' and plugins")
+	err = cmdGoBuild.Run()
+	if err != nil {
+		return errors.Wrap(err, "go build failed")
+	}
+	log.Infof("Successfully compiled executable binary for %s", b.opts.TargetOS)
+	if b.opts.TargetOS == "darwin" && b.opts.Mode != build.DebugMode {
+		err = darwinhacks.DyldHack(ctx, b.outputBinaryPath)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyEngineFiles copies the files of the engine to the build output. The
+// cached engine is locked while it is copied, so that another hover process
+// doesn't replace or remove it halfway through.
+func (b *builder) copyEngineFiles(ctx context.Context) error {
+	if b.opts.LocalEngine == "" {
+		lock, err := enginecache.LockEngine(ctx, b.opts.CachePath, b.engineDirectoryPath)
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
+	}
+
+	for _, engineFile := range build.EngineFiles(b.opts.TargetOS, b.opts.Mode) {
+		outputEngineFile := filepath.Join(b.outputDirectoryPath, engineFile)
+		if _, err := os.Stat(outputEngineFile); err == nil || os.IsExist(err) {
+			err = os.RemoveAll(outputEngineFile)
+			if err != nil {
+				return errors.Wrap(err, "failed to remove old engine")
+			}
+		}
+		err := copy.Copy(
+			b.engineFile(engineFile),
+			outputEngineFile,
+		)
+		if err != nil {
+			return errors.Wrapf(err, "failed to copy %s", engineFile)
+		}
+	}
+
+	err := copy.Copy(
+		b.engineFile("icudtl.dat"),
+		filepath.Join(b.outputDirectoryPath, "icudtl.dat"),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to copy icudtl.dat")
+	}
+	return nil
+}
+
+// engineFile returns the path of a file of the engine used by the build.
+func (b *builder) engineFile(name string) string {
+	if b.opts.LocalEngine != "" {
+		return b.opts.localEngineFile(name)
+	}
+	return filepath.Join(b.engineDirectoryPath, name)
+}
+
+// engineVersion returns the version of the engine used by the build.
+func (b *builder) engineVersion() string {
+	if b.opts.LocalEngine != "" {
+		return b.opts.localEngineVersion()
+	}
+	return cachedEngineVersion(b.engineDirectoryPath, b.opts.EngineVersion)
+}
+
+func (b *builder) buildEnv() ([]string, error) {
+	var cgoLdflags = os.Getenv("CGO_LDFLAGS")
+	var cgoCflags = os.Getenv("CGO_CFLAGS")
+
+	targetOS := b.opts.TargetOS
+	engineCachePath := b.engineDirectoryPath
+	outputDirPath := b.outputDirectoryPath
+
+	macosxVersionMin := "10.11"
+	if b.opts.Arch == "arm64" {
+		// Apple Silicon is only supported starting from macOS 11
+		macosxVersionMin = "11.0"
+	}
+
+	switch targetOS {
+	case "darwin":
+		if b.opts.Mode == build.DebugMode {
+			cgoLdflags += fmt.Sprintf(" -F%s -Wl,-rpath,@executable_path", engineCachePath)
+			cgoLdflags += fmt.Sprintf(" -F%s -L%s -framework FlutterEmbedder", outputDirPath, outputDirPath)
+		} else {
+			cgoLdflags += fmt.Sprintf(" -L%s -L%s", engineCachePath, outputDirPath)
+			cgoLdflags += " -lflutter_engine -Wl,-rpath,."
+		}
+		if runtime.GOOS == targetOS || b.opts.Arch == "arm64" {
+			cgoLdflags += " -mmacosx-version-min=" + macosxVersionMin
+			cgoCflags += " -mmacosx-version-min=" + macosxVersionMin
+		} else {
+			// OSX cross available in golang-cross (Docker) only supports up to 10.10
+			cgoLdflags += " -mmacosx-version-min=10.10"
+			cgoCflags += " -mmacosx-version-min=10.10"
+		}
+	case "linux":
+		cgoLdflags += fmt.Sprintf(" -L%s -L%s", engineCachePath, outputDirPath)
+		cgoLdflags += " -lflutter_engine -Wl,-rpath,$ORIGIN"
+	case "windows":
+		cgoLdflags += fmt.Sprintf(" -L%s -L%s", engineCachePath, outputDirPath)
+		cgoLdflags += " -lflutter_engine"
+	default:
+		return nil, errors.Errorf("target platform %s is not supported, cgo_ldflags not implemented", targetOS)
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if cgoFlags, ok := cfg.GoBuild.Cgo[targetOS]; ok {
+		cgoLdflags += " " + cgoFlags.Ldflags
+		cgoCflags += " " + cgoFlags.Cflags
+	}
+	if b.opts.Reproducible {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get working directory")
+		}
+		// keep the project path out of the debugging information of the C code
+		prefixMap, err := quoteFlag(fmt.Sprintf("-ffile-prefix-map=%s=.", wd))
+		if err != nil {
+			return nil, err
+		}
+		cgoCflags += " " + prefixMap
+	}
+	env := []string{
+		"GO111MODULE=on",
+		"CGO_LDFLAGS=" + cgoLdflags,
+		"CGO_CFLAGS=" + cgoCflags,
+		"GOOS=" + targetOS,
+		"GOARCH=" + b.opts.Arch,
+		"CGO_ENABLED=1",
+	}
+	if runtime.GOOS == "linux" && (targetOS != runtime.GOOS || b.opts.Arch != runtime.GOARCH) {
+		env = append(env,
+			"CC="+crossCompilerBinNames[targetOS][b.opts.Arch],
+		)
+	}
+	return env, nil
+}
+
+func (b *builder) buildCommand() ([]string, error) {
+	absPath, err := filepath.Abs(build.BuildPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to detect absolute path of %s", build.BuildPath)
+	}
+
+	currentTag, err := versioncheck.CurrentGoFlutterTag(absPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	spec, err := pubspec.LoadPubSpec()
+	if err != nil {
+		return nil, err
+	}
+
+	vmArguments := append([]string(nil), b.opts.VMArguments...)
+	var ldflags []string
+	if b.opts.Mode != build.DebugMode {
+		vmArguments = append(vmArguments, "--disable-dart-asserts")
+		if b.opts.Mode == build.ReleaseMode {
+			// profile builds keep the VM service for DevTools
+			vmArguments = append(vmArguments, "--disable-observatory")
+		}
+
+		if b.opts.TargetOS == "windows" {
+			ldflags = append(ldflags, "-H=windowsgui")
+		}
+		ldflags = append(ldflags, "-s")
+		ldflags = append(ldflags, "-w")
+	}
+	ldflags = append(ldflags, fmt.Sprintf("-X main.vmArguments=%s", strings.Join(vmArguments, ";")))
+	// overwrite go-flutter build-constants values
+	ldflags = append(ldflags, fmt.Sprintf(
+		"-X 'github.com/go-flutter-desktop/go-flutter.ProjectVersion=%s' "+
+			" -X 'github.com/go-flutter-desktop/go-flutter.PlatformVersion=%s' "+
+			" -X 'github.com/go-flutter-desktop/go-flutter.ProjectName=%s' "+
+			" -X 'github.com/go-flutter-desktop/go-flutter.ProjectOrganizationName=%s'",
+		b.opts.VersionNumber,
+		currentTag,
+		cfg.GetApplicationName(spec.Name),
+		cfg.GetOrganizationName()))
+	variables, err := goBuildVariables(cfg.GoBuild)
+	if err != nil {
+		return nil, err
+	}
+	ldflags = append(ldflags, variables...)
+	if b.opts.Reproducible {
+		// the build ID depends on the paths of the inputs
+		ldflags = append(ldflags, "-buildid=")
+	}
+
+	tags := append([]string{"opengl" + b.opts.OpenGlVersion, "no_engine_tags"}, cfg.GoBuild.Tags...)
+	outputCommand := []string{
+		"go",
+		"build",
+		"-tags=" + strings.Join(tags, ","),
+		"-o", b.outputBinaryPath,
+		"-v",
+	}
+	if cfg.GoBuild.Trimpath || b.opts.Reproducible {
+		outputCommand = append(outputCommand, "-trimpath")
+	}
+	if b.opts.Reproducible {
+		outputCommand = append(outputCommand, "-buildvcs=false")
+	}
+	if cfg.GoBuild.Race && b.opts.Mode == build.DebugMode {
+		outputCommand = append(outputCommand, "-race")
+	}
+	if b.opts.DisableGoOptimizations {
+		outputCommand = append(outputCommand, "-gcflags=all=-N -l")
+	}
+	outputCommand = append(outputCommand, fmt.Sprintf("-ldflags=%s", strings.Join(ldflags, " ")))
+	outputCommand = append(outputCommand, dotSlash+"cmd")
+	return outputCommand, nil
+}
+
+// goBuildVariables returns the -X ldflags of the variables of the go-build
+// section of hover.yaml, sorted by name, with the environment variables of
+// their values expanded. The values are quoted with quoteFlag, they can't
+// contain both single and double quotes.
+func goBuildVariables(goBuild config.GoBuildConfig) ([]string, error) {
+	names := make([]string, 0, len(goBuild.Variables))
+	for name := range goBuild.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ldflags := make([]string, 0, len(names))
+	for _, name := range names {
+		variable, err := quoteFlag(name + "=" + os.ExpandEnv(goBuild.Variables[name]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid go-build variable %s", name)
+		}
+		ldflags = append(ldflags, "-X "+variable)
+	}
+	return ldflags, nil
+}
+
+// reportStage records the duration of a build stage, if a report is requested.
+func (opts BuildOptions) reportStage(name, target string, skipped bool, start time.Time) {
+	if opts.Report == nil {
+		return
+	}
+	opts.Report.AddStage(name, target, skipped, start)
+}
+
+// quoteCgoFlag quotes flag for the CGO_ flags variables, which are split on
+// spaces unless a field is quoted. The quotes can't be escaped.
+func quoteFlag(flag string) (string, error) {
+	if !strings.ContainsAny(flag, " \t\n\r'\"") {
+		return flag, nil
+	}
+	if !strings.Contains(flag, "'") {
+		return "'" + flag + "'", nil
+	}
+	if !strings.Contains(flag, `"`) {
+		return `"` + flag + `"`, nil
+	}
+	return "", errors.Errorf("the cgo flag %s can't be quoted, it contains both quotes", flag)
+}
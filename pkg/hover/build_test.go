@@ -0,0 +1,48 @@
+package hover
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-flutter-desktop/hover/internal/config"
+)
+
+func TestQuoteFlag(t *testing.T) {
+	flag, err := quoteFlag("-ffile-prefix-map=/home/user/app=.")
+	require.NoError(t, err)
+	require.Equal(t, "-ffile-prefix-map=/home/user/app=.", flag)
+
+	flag, err = quoteFlag("-ffile-prefix-map=/home/user/my app=.")
+	require.NoError(t, err)
+	require.Equal(t, "'-ffile-prefix-map=/home/user/my app=.'", flag)
+
+	flag, err = quoteFlag("-ffile-prefix-map=/home/user/user's app=.")
+	require.NoError(t, err)
+	require.Equal(t, `"-ffile-prefix-map=/home/user/user's app=."`, flag)
+
+	_, err = quoteFlag(`-ffile-prefix-map=/home/user/"user's" app=.`)
+	require.Error(t, err)
+}
+
+func TestGoBuildVariables(t *testing.T) {
+	t.Setenv("HOVER_TEST_USER", "user's name")
+	ldflags, err := goBuildVariables(config.GoBuildConfig{
+		Variables: map[string]string{
+			"main.version": "1.0.0",
+			"main.builtBy": "$HOVER_TEST_USER",
+			"main.motto":   "it's a go app",
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`-X "main.builtBy=user's name"`,
+		`-X "main.motto=it's a go app"`,
+		"-X main.version=1.0.0",
+	}, ldflags)
+
+	_, err = goBuildVariables(config.GoBuildConfig{
+		Variables: map[string]string{"main.motto": `it's "go"`},
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,72 @@
+package hover
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-flutter-desktop/hover/internal/build"
+	"github.com/go-flutter-desktop/hover/internal/enginecache"
+)
+
+// PrepareEngine downloads the flutter engine used to build opts into the
+// cache, unless the cache already contains the required version. Nothing is
+// downloaded when opts uses a local engine.
+func PrepareEngine(ctx context.Context, opts BuildOptions) error {
+	opts, err := opts.withEngineDefaults()
+	if err != nil {
+		return err
+	}
+	if opts.LocalEngine != "" {
+		return nil
+	}
+	if err := build.ValidateTargetOS(opts.TargetOS); err != nil {
+		return err
+	}
+	if err := build.ValidateArch(opts.Arch); err != nil {
+		return err
+	}
+	return enginecache.ValidateOrUpdateEngine(ctx, opts.TargetOS, opts.Arch, opts.CachePath, opts.EngineVersion, opts.EngineMirror, opts.Mode)
+}
+
+// EngineCachePath returns the directory of the cached engine used to build
+// opts. When opts.EngineVersion is empty, the engine version required by the
+// installed flutter SDK is used.
+func EngineCachePath(ctx context.Context, opts BuildOptions) (string, error) {
+	opts, err := opts.withEngineDefaults()
+	if err != nil {
+		return "", err
+	}
+	engineVersion, err := enginecache.ResolveEngineVersion(ctx, opts.EngineVersion)
+	if err != nil {
+		return "", err
+	}
+	return enginecache.EngineCachePath(opts.TargetOS, opts.Arch, opts.CachePath, engineVersion, opts.Mode), nil
+}
+
+// CachedEngineVersion returns the version of the engine in the cache, the
+// requested engine version is returned when the cache doesn't contain one.
+// When opts uses a local engine, its path is returned.
+func CachedEngineVersion(ctx context.Context, opts BuildOptions) string {
+	if opts.LocalEngine != "" {
+		opts, err := opts.withEngineDefaults()
+		if err != nil {
+			return opts.EngineVersion
+		}
+		return opts.localEngineVersion()
+	}
+	engineCachePath, err := EngineCachePath(ctx, opts)
+	if err != nil {
+		return opts.EngineVersion
+	}
+	return cachedEngineVersion(engineCachePath, opts.EngineVersion)
+}
+
+func cachedEngineVersion(engineCachePath, requiredEngineVersion string) string {
+	cachedVersion, err := ioutil.ReadFile(filepath.Join(engineCachePath, "version"))
+	if err != nil {
+		return requiredEngineVersion
+	}
+	return strings.TrimSpace(string(cachedVersion))
+}
@@ -0,0 +1,84 @@
+package hover
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/pkg/errors"
+
+	"github.com/go-flutter-desktop/hover/internal/build"
+	"github.com/go-flutter-desktop/hover/internal/log"
+)
+
+// names of the hooks of hover.yaml
+const (
+	preFlutterHook  = "pre-flutter"
+	postFlutterHook = "post-flutter"
+	preGoHook       = "pre-go"
+	postGoHook      = "post-go"
+	prePackageHook  = "pre-package"
+	postPackageHook = "post-package"
+)
+
+// runHook runs the shell command of a hook of hover.yaml, with the paths and
+// parameters of the build in its environment. extraEnv is added to that
+// environment. An empty command does nothing, a failing command fails the
+// build.
+func (opts BuildOptions) runHook(ctx context.Context, name, command string, extraEnv ...string) error {
+	if command == "" {
+		return nil
+	}
+	env, err := opts.hookEnv(name)
+	if err != nil {
+		return err
+	}
+	var cmdHook *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmdHook = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmdHook = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmdHook.Env = append(append(os.Environ(), env...), extraEnv...)
+	cmdHook.Stdin = os.Stdin
+	cmdHook.Stdout = os.Stdout
+	cmdHook.Stderr = os.Stderr
+	log.Infof("Running the %s hook", name)
+	err = cmdHook.Run()
+	if err != nil {
+		return errors.Wrapf(err, "the %s hook failed", name)
+	}
+	return nil
+}
+
+// hookEnv returns the environment variables describing the build to the
+// hooks.
+func (opts BuildOptions) hookEnv(name string) ([]string, error) {
+	outputDirectoryPath, err := build.OutputDirectoryPath(opts.TargetOS, opts.Arch, opts.Mode)
+	if err != nil {
+		return nil, err
+	}
+	outputBinaryPath, err := outputBinaryPath(opts.TargetOS, opts.Arch, opts.Mode)
+	if err != nil {
+		return nil, err
+	}
+	outputDirectoryPath, err = filepath.Abs(outputDirectoryPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve the output directory")
+	}
+	outputBinaryPath, err = filepath.Abs(outputBinaryPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve the executable path")
+	}
+	return []string{
+		"HOVER_HOOK=" + name,
+		"HOVER_TARGET_OS=" + opts.TargetOS,
+		"HOVER_ARCH=" + opts.Arch,
+		"HOVER_MODE=" + opts.Mode.Name,
+		"HOVER_VERSION=" + opts.VersionNumber,
+		"HOVER_OUTPUT_DIR=" + outputDirectoryPath,
+		"HOVER_EXECUTABLE=" + outputBinaryPath,
+	}, nil
+}
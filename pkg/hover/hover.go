@@ -0,0 +1,266 @@
+// Package hover exposes the build pipeline of the hover command line tool so
+// that it can be driven from Go programs, such as release orchestrators,
+// without shelling out to the hover binary.
+//
+// Like the hover commands, the functions of this package operate on the
+// flutter project in the current working directory and read its hover.yaml
+// and pubspec.yaml files.
+package hover
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/pkg/errors"
+
+	"github.com/go-flutter-desktop/hover/internal/aot"
+	"github.com/go-flutter-desktop/hover/internal/build"
+	"github.com/go-flutter-desktop/hover/internal/config"
+	"github.com/go-flutter-desktop/hover/internal/enginecache"
+	"github.com/go-flutter-desktop/hover/internal/packaging"
+	"github.com/go-flutter-desktop/hover/internal/pubspec"
+)
+
+// Mode is the flutter build mode of an app.
+type Mode = build.Mode
+
+// The build modes supported by hover.
+var (
+	DebugMode      = build.DebugMode
+	JitReleaseMode = build.JitReleaseMode
+	ReleaseMode    = build.ReleaseMode
+	ProfileMode    = build.ProfileMode
+)
+
+// Report collects the stages and artifacts of a build.
+type Report = build.Report
+
+// PackagingTask is a packaging format, such as LinuxDebTask.
+type PackagingTask = packaging.Task
+
+// DefaultArch is the architecture used when BuildOptions.Arch is empty.
+const DefaultArch = build.DefaultArch
+
+// BuildOptions contains the parameters of a build. Empty fields fall back to
+// the values of hover.yaml, pubspec.yaml or the hover defaults, the same way
+// the flags of `hover build` do.
+type BuildOptions struct {
+	// TargetOS is the OS to build for: "linux", "darwin" or "windows".
+	TargetOS string
+	// Arch is the architecture to build for, defaults to DefaultArch.
+	Arch string
+	// Mode is the build mode, defaults to ReleaseMode.
+	Mode Mode
+	// FlutterTarget is the main entry-point file of the application.
+	FlutterTarget string
+	// Flavor selects the go/hover-<flavor>.yaml config instead of
+	// go/hover.yaml, like the --flavor flag.
+	Flavor string
+	// CachePath is the directory in which the flutter engine is cached.
+	CachePath string
+	// EngineVersion is the flutter engine version to use, defaults to the
+	// version required by the installed flutter SDK.
+	EngineVersion string
+	// EngineMirror is the base URL or URL template of the server the engine
+	// is downloaded from. It defaults to the HOVER_ENGINE_MIRROR environment
+	// variable, then to the engine-mirror field of hover.yaml.
+	EngineMirror string
+	// LocalEngineSrcPath is the src directory of a flutter engine checkout,
+	// defaults to the FLUTTER_ENGINE environment variable.
+	LocalEngineSrcPath string
+	// LocalEngine is the name of a directory in LocalEngineSrcPath/out, e.g.
+	// host_release. When set, the engine and the AOT build tools of that
+	// local engine build are used instead of a cached engine.
+	LocalEngine string
+	// OpenGlVersion is the OpenGL version used by go-flutter.
+	OpenGlVersion string
+	// VersionNumber is the version of the app used in the build and the
+	// packages, defaults to the version of pubspec.yaml.
+	VersionNumber string
+	// DartDefines are KEY=VALUE compile-time constants of the dart code,
+	// like the --dart-define flags of the flutter tool. They override the
+	// constants of DartDefineFiles, which override the dart-defines of
+	// hover.yaml.
+	DartDefines []string
+	// DartDefineFiles are JSON or .env files of compile-time constants.
+	DartDefineFiles []string
+	// VMArguments are passed to the dart VM when the app starts.
+	VMArguments []string
+	// Obfuscate renames the dart symbols of AOT builds. It requires
+	// SplitDebugInfo, to be able to symbolicate the stack traces.
+	Obfuscate bool
+	// SplitDebugInfo is a directory where the debugging information of AOT
+	// builds is saved instead of being included in the app, in a
+	// subdirectory per VersionNumber.
+	SplitDebugInfo string
+	// FlutterBundleOS is a target OS which was already built with the same
+	// options. Its flutter assets are reused instead of running
+	// `flutter build bundle` again.
+	FlutterBundleOS string
+
+	// SkipFlutter skips the flutter stage.
+	SkipFlutter bool
+	// SkipEmbedder skips the go stage.
+	SkipEmbedder bool
+	// SkipEngineDownload uses the cached engine without validating it.
+	SkipEngineDownload bool
+	// Force rebuilds the stages even if their inputs didn't change.
+	Force bool
+	// IgnoreHostOS allows AOT builds that the host doesn't seem to support.
+	IgnoreHostOS bool
+	// Reproducible makes the go binary and the packages identical between
+	// builds of the same sources. The timestamps are taken from the
+	// SOURCE_DATE_EPOCH environment variable, which is set to the time of
+	// the last git commit when it is missing.
+	Reproducible bool
+	// DisableGoOptimizations builds the go code without optimizations and
+	// inlining, to step through it with a debugger such as delve.
+	DisableGoOptimizations bool
+
+	// Report, when not nil, receives the duration of each stage.
+	Report *Report
+}
+
+// withDefaults returns a copy of opts where empty fields are replaced by
+// their default value.
+func (opts BuildOptions) withDefaults() (BuildOptions, error) {
+	opts, err := opts.withEngineDefaults()
+	if err != nil {
+		return opts, err
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return opts, err
+	}
+	if opts.FlutterTarget == "" || opts.FlutterTarget == config.BuildTargetDefault {
+		opts.FlutterTarget = config.BuildTargetDefault
+		if cfg.Target != "" {
+			opts.FlutterTarget = cfg.Target
+		}
+	}
+	if opts.EngineVersion == config.BuildEngineDefault && cfg.Engine != "" {
+		opts.EngineVersion = cfg.Engine
+	}
+	if opts.OpenGlVersion == "" || opts.OpenGlVersion == config.BuildOpenGlVersionDefault {
+		opts.OpenGlVersion = config.BuildOpenGlVersionDefault
+		if cfg.OpenGL != "" {
+			opts.OpenGlVersion = cfg.OpenGL
+		}
+	}
+	opts.DartDefines, err = build.MergeDartDefines(cfg.DartDefines, opts.DartDefineFiles, opts.DartDefines)
+	if err != nil {
+		return opts, err
+	}
+	if opts.VersionNumber == "" {
+		spec, err := pubspec.LoadPubSpec()
+		if err != nil {
+			return opts, err
+		}
+		opts.VersionNumber = spec.GetVersion()
+	}
+	return opts, nil
+}
+
+// withEngineDefaults returns a copy of opts where the empty arch, mode, cache
+// path, engine mirror and local engine path are replaced by their default
+// value. Unlike withDefaults, it only reads hover.yaml, when it exists, so
+// that the engine can be prepared outside of a flutter project.
+func (opts BuildOptions) withEngineDefaults() (BuildOptions, error) {
+	if opts.Flavor != "" {
+		err := config.SetHoverFlavor(opts.Flavor)
+		if err != nil {
+			return opts, err
+		}
+	}
+	if opts.Arch == "" {
+		opts.Arch = build.DefaultArch
+	}
+	if opts.Mode == (Mode{}) {
+		opts.Mode = ReleaseMode
+	}
+	if opts.CachePath == "" {
+		opts.CachePath = enginecache.DefaultCachePath()
+		if opts.CachePath == "" {
+			return opts, errors.New("missing cache path")
+		}
+	}
+	if opts.EngineMirror == "" {
+		opts.EngineMirror = os.Getenv(enginecache.MirrorEnv)
+	}
+	if opts.EngineMirror == "" && fileExists(filepath.Join(build.BuildPath, config.GetHoverFlavorYaml())) {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return opts, err
+		}
+		opts.EngineMirror = cfg.EngineMirror
+	}
+	if opts.LocalEngine != "" && opts.LocalEngineSrcPath == "" {
+		opts.LocalEngineSrcPath = os.Getenv("FLUTTER_ENGINE")
+	}
+	return opts, nil
+}
+
+// validate returns an error when the options can't be built on this host.
+func (opts BuildOptions) validate() error {
+	if err := build.ValidateTargetOS(opts.TargetOS); err != nil {
+		return err
+	}
+	if err := build.ValidateArch(opts.Arch); err != nil {
+		return err
+	}
+	if err := opts.validateLocalEngine(); err != nil {
+		return err
+	}
+	if opts.Obfuscate && opts.SplitDebugInfo == "" {
+		return errors.New("obfuscated builds require a directory to save the debugging information, set --split-debug-info")
+	}
+	if (opts.Obfuscate || opts.SplitDebugInfo != "") && !opts.Mode.IsAot {
+		return errors.Errorf("obfuscation and split debugging information are only supported by the release and profile modes, not %s", opts.Mode.Name)
+	}
+	if !opts.Mode.IsAot || opts.IgnoreHostOS {
+		return nil
+	}
+	if opts.Arch != runtime.GOARCH {
+		// gen_snapshot from the engine builds only runs on the architecture it targets
+		return errors.Errorf("AOT builds for %s can only be made on a %s host, use the JIT release mode instead", opts.Arch, opts.Arch)
+	}
+	_, err := aot.NewRunner(opts.TargetOS)
+	return err
+}
+
+// OutputDirectoryPath returns the directory containing the app built with
+// opts.
+func OutputDirectoryPath(opts BuildOptions) (string, error) {
+	opts, err := opts.withEngineDefaults()
+	if err != nil {
+		return "", err
+	}
+	return build.OutputDirectoryPath(opts.TargetOS, opts.Arch, opts.Mode)
+}
+
+// OutputBinaryPath returns the path of the app executable built with opts.
+func OutputBinaryPath(opts BuildOptions) (string, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return "", err
+	}
+	return outputBinaryPath(opts.TargetOS, opts.Arch, opts.Mode)
+}
+
+func outputBinaryPath(targetOS, arch string, mode Mode) (string, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return "", err
+	}
+	spec, err := pubspec.LoadPubSpec()
+	if err != nil {
+		return "", err
+	}
+	return build.OutputBinaryPath(cfg.GetExecutableName(spec.Name), targetOS, arch, mode)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
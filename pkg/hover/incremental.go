@@ -0,0 +1,302 @@
+package hover
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/go-flutter-desktop/hover/internal/build"
+	"github.com/go-flutter-desktop/hover/internal/config"
+	"github.com/go-flutter-desktop/hover/internal/log"
+	"github.com/go-flutter-desktop/hover/internal/pubspec"
+)
+
+// names of the build stages that can be skipped when their inputs are
+// unchanged, used as stamp file names.
+const (
+	flutterStage  = "flutter"
+	embedderStage = "embedder"
+)
+
+// buildStages runs the flutter and go build stages. A stage is skipped when
+// its inputs didn't change since the last build and its outputs are still
+// present, unless opts.Force is set. The pre hook of a stage runs before its
+// inputs are checked, as it may change them, the post hook only runs when the
+// stage was built.
+func (b *builder) buildStages(ctx context.Context) error {
+	opts := b.opts
+	targetOS := opts.TargetOS
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	if !opts.SkipFlutter {
+		err = opts.runHook(ctx, preFlutterHook, cfg.Hooks.PreFlutter)
+		if err != nil {
+			return err
+		}
+	}
+	if !opts.SkipEmbedder {
+		err = opts.runHook(ctx, preGoHook, cfg.Hooks.PreGo)
+		if err != nil {
+			return err
+		}
+	}
+
+	embedderUpToDate := false
+	if !opts.Force && !opts.SkipEmbedder && fileExists(b.outputBinaryPath) {
+		hash, err := b.embedderStageHash()
+		if err != nil {
+			return err
+		}
+		embedderUpToDate = build.StampUpToDate(targetOS, opts.Arch, opts.Mode, embedderStage, hash)
+	}
+
+	if !opts.SkipFlutter {
+		start := time.Now()
+		flutterUpToDate := false
+		if !opts.Force && fileExists(filepath.Join(b.outputDirectoryPath, "flutter_assets")) &&
+			(b.debugInfoPath() == "" || fileExists(b.debugInfoPath())) &&
+			(b.obfuscationMapPath() == "" || fileExists(b.obfuscationMapPath())) {
+			hash, err := b.flutterStageHash()
+			if err != nil {
+				return err
+			}
+			flutterUpToDate = build.StampUpToDate(targetOS, opts.Arch, opts.Mode, flutterStage, hash)
+		}
+		if flutterUpToDate {
+			log.Infof("Flutter bundle is up to date, skipping")
+		} else {
+			err := build.RemoveStamp(targetOS, opts.Arch, opts.Mode, flutterStage)
+			if err != nil {
+				return err
+			}
+			if embedderUpToDate {
+				// keep the go binary, only the flutter outputs are rebuilt
+				err = b.cleanFlutterOutputs()
+			} else {
+				err = build.RemoveStamp(targetOS, opts.Arch, opts.Mode, embedderStage)
+				if err == nil {
+					err = b.cleanBuildOutputsDir()
+				}
+			}
+			if err != nil {
+				return err
+			}
+			if opts.FlutterBundleOS == "" {
+				err = b.buildFlutterBundle(ctx)
+			} else {
+				err = b.reuseFlutterBundle(ctx)
+			}
+			if err != nil {
+				return err
+			}
+			// `flutter build bundle` may update pubspec.lock, hash after the build
+			hash, err := b.flutterStageHash()
+			if err != nil {
+				return err
+			}
+			err = build.WriteStamp(targetOS, opts.Arch, opts.Mode, flutterStage, hash)
+			if err != nil {
+				return err
+			}
+			err = opts.runHook(ctx, postFlutterHook, cfg.Hooks.PostFlutter)
+			if err != nil {
+				return err
+			}
+		}
+		opts.reportStage(flutterStage, targetOS, flutterUpToDate, start)
+	}
+
+	if !opts.SkipEmbedder {
+		start := time.Now()
+		if embedderUpToDate {
+			log.Infof("Go binary is up to date, skipping")
+		} else {
+			err := build.RemoveStamp(targetOS, opts.Arch, opts.Mode, embedderStage)
+			if err != nil {
+				return err
+			}
+			err = b.buildGoBinary(ctx)
+			if err != nil {
+				return err
+			}
+			// go-flutter may have been upgraded during the build, hash after the build
+			hash, err := b.embedderStageHash()
+			if err != nil {
+				return err
+			}
+			err = build.WriteStamp(targetOS, opts.Arch, opts.Mode, embedderStage, hash)
+			if err != nil {
+				return err
+			}
+			err = opts.runHook(ctx, postGoHook, cfg.Hooks.PostGo)
+			if err != nil {
+				return err
+			}
+		}
+		opts.reportStage(embedderStage, targetOS, embedderUpToDate, start)
+	}
+	return nil
+}
+
+// flutterStageHash hashes the inputs of the flutter bundle and AOT snapshot.
+func (b *builder) flutterStageHash() (string, error) {
+	paths := []string{"lib", "pubspec.yaml", "pubspec.lock", b.opts.FlutterTarget}
+	spec, err := pubspec.LoadPubSpec()
+	if err != nil {
+		return "", err
+	}
+	paths = append(paths, pubspecAssetPaths(spec, "")...)
+	// the sources of the path dependencies aren't versioned by pubspec.lock
+	dependencies, err := pubspec.PathDependencies()
+	if err != nil {
+		return "", err
+	}
+	for _, dependency := range dependencies {
+		paths = append(paths, filepath.Join(dependency, "lib"), filepath.Join(dependency, "pubspec.yaml"))
+		dependencySpec, err := pubspec.ReadPubSpecFile(filepath.Join(dependency, "pubspec.yaml"))
+		if err == nil {
+			paths = append(paths, pubspecAssetPaths(*dependencySpec, dependency)...)
+		}
+	}
+	hash, err := build.HashInputs(paths,
+		b.opts.TargetOS,
+		b.opts.Arch,
+		b.opts.Mode.Name,
+		b.opts.FlutterTarget,
+		b.engineVersion(),
+		fmt.Sprint(b.opts.Obfuscate),
+		b.debugInfoPath(),
+		strings.Join(b.opts.DartDefines, "\n"),
+	)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to hash the flutter inputs")
+	}
+	return hash, nil
+}
+
+// embedderStageHash hashes the inputs of the go binary.
+func (b *builder) embedderStageHash() (string, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return "", err
+	}
+	spec, err := pubspec.LoadPubSpec()
+	if err != nil {
+		return "", err
+	}
+	variables, err := goBuildVariables(cfg.GoBuild)
+	if err != nil {
+		return "", err
+	}
+	paths, err := goSourcePaths()
+	if err != nil {
+		return "", err
+	}
+	paths = append(paths,
+		filepath.Join(build.BuildPath, "go.mod"),
+		filepath.Join(build.BuildPath, "go.sum"),
+		filepath.Join(build.BuildPath, "cmd"),
+		filepath.Join(build.BuildPath, "assets"),
+		b.intermediatesDirectoryPath,
+	)
+	hash, err := build.HashInputs(paths,
+		b.opts.TargetOS,
+		b.opts.Arch,
+		b.opts.Mode.Name,
+		b.opts.OpenGlVersion,
+		b.opts.VersionNumber,
+		strings.Join(b.opts.VMArguments, ";"),
+		spec.Name,
+		fmt.Sprintf("%#v", cfg),
+		strings.Join(variables, " "),
+		fmt.Sprint(b.opts.Reproducible),
+		fmt.Sprint(b.opts.DisableGoOptimizations),
+		b.engineVersion(),
+	)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to hash the go inputs")
+	}
+	return hash, nil
+}
+
+// goSourcePaths returns the go files of the go directory outside of go/cmd,
+// which is hashed as a whole, and of go/build, which contains the outputs
+// of the build.
+func goSourcePaths() ([]string, error) {
+	var paths []string
+	err := filepath.Walk(build.BuildPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			switch path {
+			case filepath.Join(build.BuildPath, "build"), filepath.Join(build.BuildPath, "cmd"):
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(path, ".go") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "failed to list the go files in %s", build.BuildPath)
+	}
+	return paths, nil
+}
+
+// pubspecAssetPaths returns the assets and fonts declared in the pubspec of
+// the package in dir.
+func pubspecAssetPaths(spec pubspec.PubSpec, dir string) []string {
+	var paths []string
+	if assets, ok := spec.Flutter["assets"].([]interface{}); ok {
+		for _, asset := range assets {
+			if path, ok := asset.(string); ok {
+				paths = append(paths, filepath.Join(dir, path))
+			}
+		}
+	}
+	if fonts, ok := spec.Flutter["fonts"].([]interface{}); ok {
+		for _, font := range fonts {
+			family, ok := font.(map[interface{}]interface{})
+			if !ok {
+				continue
+			}
+			files, ok := family["fonts"].([]interface{})
+			if !ok {
+				continue
+			}
+			for _, file := range files {
+				if f, ok := file.(map[interface{}]interface{}); ok {
+					if path, ok := f["asset"].(string); ok {
+						paths = append(paths, filepath.Join(dir, path))
+					}
+				}
+			}
+		}
+	}
+	return paths
+}
+
+// cleanFlutterOutputs removes the outputs of the flutter stage, keeping the
+// rest of the output directory.
+func (b *builder) cleanFlutterOutputs() error {
+	log.Printf("Cleaning the flutter outputs")
+	for _, name := range []string{"flutter_assets", "libapp.so"} {
+		path := filepath.Join(b.outputDirectoryPath, name)
+		err := os.RemoveAll(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to remove %s", path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package hover
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/go-flutter-desktop/hover/internal/build"
+)
+
+// localEnginePath returns the output directory of the local engine build, or
+// an empty string when no local engine is used.
+func (opts BuildOptions) localEnginePath() string {
+	if opts.LocalEngine == "" {
+		return ""
+	}
+	return filepath.Join(opts.LocalEngineSrcPath, "out", opts.LocalEngine)
+}
+
+// validateLocalEngine returns an error when the local engine build lacks the
+// files needed to build opts.
+func (opts BuildOptions) validateLocalEngine() error {
+	if opts.LocalEngine == "" {
+		return nil
+	}
+	if opts.LocalEngineSrcPath == "" {
+		return errors.New("a local engine requires the path of the engine sources, set --local-engine-src-path or FLUTTER_ENGINE")
+	}
+	if strings.ContainsAny(opts.LocalEngine, `/\`) {
+		return errors.Errorf("the local engine %q must be the name of a directory in %s", opts.LocalEngine, filepath.Join(opts.LocalEngineSrcPath, "out"))
+	}
+	localEnginePath := opts.localEnginePath()
+	for _, file := range append([]string{"icudtl.dat"}, build.EngineFiles(opts.TargetOS, opts.Mode)...) {
+		_, err := os.Stat(filepath.Join(localEnginePath, file))
+		if err != nil {
+			return errors.Errorf("the local engine at %s doesn't contain %s, was it built for %s?", localEnginePath, file, build.TargetName(opts.TargetOS, opts.Arch, opts.Mode))
+		}
+	}
+	return nil
+}
+
+// localEngineFile returns the path of the engine file name in the local engine
+// build. The build tools aren't at the same location in the output of an
+// engine build as in the engines downloaded by hover.
+func (opts BuildOptions) localEngineFile(name string) string {
+	localEnginePath := opts.localEnginePath()
+	candidates := []string{name}
+	switch strings.TrimSuffix(name, build.ExecutableExtension(opts.TargetOS)) {
+	case "dart":
+		candidates = []string{filepath.Join("dart-sdk", "bin", name), name}
+	case "gen_snapshot":
+		candidates = []string{name, filepath.Join("clang_x64", name), filepath.Join("clang_arm64", name)}
+	}
+	for _, candidate := range candidates {
+		if _, err := os.Stat(filepath.Join(localEnginePath, candidate)); err == nil {
+			return filepath.Join(localEnginePath, candidate)
+		}
+	}
+	return filepath.Join(localEnginePath, name)
+}
+
+// localEngineVersion identifies the local engine build in the stamps and
+// reports. It changes when the engine is rebuilt.
+func (opts BuildOptions) localEngineVersion() string {
+	localEnginePath := opts.localEnginePath()
+	version := "local:" + localEnginePath
+	fileInfo, err := os.Stat(filepath.Join(localEnginePath, build.EngineFiles(opts.TargetOS, opts.Mode)[0]))
+	if err == nil {
+		version += fmt.Sprintf("@%d", fileInfo.ModTime().Unix())
+	}
+	return version
+}
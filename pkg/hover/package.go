@@ -0,0 +1,93 @@
+package hover
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/go-flutter-desktop/hover/internal/config"
+	"github.com/go-flutter-desktop/hover/internal/packaging"
+)
+
+// The packaging formats supported by hover.
+var (
+	DarwinBundleTask  PackagingTask = packaging.DarwinBundleTask
+	DarwinDmgTask     PackagingTask = packaging.DarwinDmgTask
+	DarwinPkgTask     PackagingTask = packaging.DarwinPkgTask
+	LinuxAppImageTask PackagingTask = packaging.LinuxAppImageTask
+	LinuxDebTask      PackagingTask = packaging.LinuxDebTask
+	LinuxPkgTask      PackagingTask = packaging.LinuxPkgTask
+	LinuxRpmTask      PackagingTask = packaging.LinuxRpmTask
+	LinuxSnapTask     PackagingTask = packaging.LinuxSnapTask
+	WindowsMsiTask    PackagingTask = packaging.WindowsMsiTask
+)
+
+// LookupPackagingTask returns the packaging task of a format by name, such
+// as "linux-deb".
+func LookupPackagingTask(name string) (PackagingTask, error) {
+	for _, task := range []PackagingTask{
+		DarwinBundleTask,
+		DarwinDmgTask,
+		DarwinPkgTask,
+		LinuxAppImageTask,
+		LinuxDebTask,
+		LinuxPkgTask,
+		LinuxRpmTask,
+		LinuxSnapTask,
+		WindowsMsiTask,
+	} {
+		if task.Name() == name {
+			return task, nil
+		}
+	}
+	return nil, errors.Errorf("unknown packaging format %s", name)
+}
+
+// Package packages the app built with opts using task, and returns the path
+// of the package. The app must have been built by Build first.
+func Package(ctx context.Context, task PackagingTask, opts BuildOptions) (string, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return "", err
+	}
+	err = task.AssertInitialized()
+	if err != nil {
+		return "", err
+	}
+	err = task.AssertSupported()
+	if err != nil {
+		return "", err
+	}
+	if opts.Reproducible {
+		err = setSourceDateEpoch(ctx)
+		if err != nil {
+			return "", err
+		}
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return "", err
+	}
+	formatEnv := "HOVER_PACKAGING_FORMAT=" + task.Name()
+	err = opts.runHook(ctx, prePackageHook, cfg.Hooks.PrePackage, formatEnv)
+	if err != nil {
+		return "", err
+	}
+	start := time.Now()
+	path, err := task.Pack(ctx, opts.VersionNumber, opts.Arch, opts.Mode, opts.Reproducible)
+	opts.reportStage("package", task.Name(), false, start)
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to resolve the package path")
+	}
+	err = opts.runHook(ctx, postPackageHook, cfg.Hooks.PostPackage, formatEnv, "HOVER_PACKAGE="+absPath)
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,16 @@
+package hover
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupPackagingTask(t *testing.T) {
+	task, err := LookupPackagingTask("linux-deb")
+	require.NoError(t, err)
+	require.Equal(t, LinuxDebTask, task)
+
+	_, err = LookupPackagingTask("linux-tar")
+	require.Error(t, err)
+}
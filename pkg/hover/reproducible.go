@@ -0,0 +1,36 @@
+package hover
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/go-flutter-desktop/hover/internal/log"
+)
+
+// sourceDateEpochEnv is the environment variable of the timestamp used by
+// reproducible builds, see https://reproducible-builds.org/specs/source-date-epoch/
+const sourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+
+// setSourceDateEpoch sets SOURCE_DATE_EPOCH, when it isn't set, to the time
+// of the last git commit. The packaging and the tools run by hover then use
+// that timestamp instead of the current time.
+func setSourceDateEpoch(ctx context.Context) error {
+	if epoch := os.Getenv(sourceDateEpochEnv); epoch != "" {
+		if _, err := strconv.ParseInt(epoch, 10, 64); err != nil {
+			return errors.Errorf("%s must be a number of seconds, not %q", sourceDateEpochEnv, epoch)
+		}
+		return nil
+	}
+	output, err := exec.CommandContext(ctx, "git", "log", "-1", "--pretty=%ct").Output()
+	if err != nil {
+		return errors.Errorf("reproducible builds need a timestamp, set %s or build in a git repository", sourceDateEpochEnv)
+	}
+	epoch := strings.TrimSpace(string(output))
+	log.Infof("Using the time of the last git commit as %s: %s", sourceDateEpochEnv, epoch)
+	return os.Setenv(sourceDateEpochEnv, epoch)
+}